@@ -17,11 +17,16 @@
 package commands
 
 import (
+  "bufio"
   "encoding/base64"
+  "encoding/json"
   "errors"
   "fmt"
+  "io"
+  "os"
   "path/filepath"
   "strings"
+  "sync"
 
   "../../go-whisk/whisk"
   "../wski18n"
@@ -66,6 +71,10 @@ var actionCreateCmd = &cobra.Command{
       return whiskErr
     }
 
+    if err = checkActionPolicy(action, false); err != nil {
+      return err
+    }
+
     _, _, err = client.Actions.Insert(action, false)
     if err != nil {
       whisk.Debug(whisk.DbgError, "client.Actions.Insert(%#v, false) error: %s\n", action, err)
@@ -104,7 +113,19 @@ var actionUpdateCmd = &cobra.Command{
       return whiskErr
     }
 
-    _, _, err = client.Actions.Insert(action, true)
+    if err = mergeUpdateKeyValues("action", action.Name, &action.Parameters, &action.Annotations); err != nil {
+      return err
+    }
+
+    if err = checkActionPolicy(action, true); err != nil {
+      return err
+    }
+
+    if len(flags.common.ifVersion) > 0 {
+      _, _, err = client.Actions.InsertIfVersion(action, flags.common.ifVersion)
+    } else {
+      _, _, err = client.Actions.Insert(action, true)
+    }
     if err != nil {
       whisk.Debug(whisk.DbgError, "client.Actions.Insert(%#v, %t, false) error: %s\n", action, err)
       errMsg := wski18n.T("Unable to update action: {{.err}}", map[string]interface{}{"err": err})
@@ -160,6 +181,10 @@ var actionInvokeCmd = &cobra.Command{
 
     }
 
+    if len(flags.action.batch) > 0 {
+      return invokeActionBatch(qName, parameters, flags.action.batch)
+    }
+
     outputStream := color.Output
 
     res, _, err := client.Actions.Invoke(qName.entityName, parameters, flags.common.blocking, flags.action.result)
@@ -290,22 +315,69 @@ var actionDeleteCmd = &cobra.Command{
     }
     client.Namespace = qName.namespace
 
-    _, err = client.Actions.Delete(qName.entityName)
-    if err != nil {
-      whisk.Debug(whisk.DbgError, "client.Actions.Delete(%s) error: %s\n", qName.entityName, err)
-      errMsg := wski18n.T("Unable to delete action: {{.err}}", map[string]interface{}{"err": err})
-      whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
-        whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
-      return whiskErr
+    // The references are looked up once, before the deletion is confirmed, and each action deletes its own
+    var references map[string]*ActionReferences
+    var related func(names []string) ([]string, error)
+    if flags.action.cascade {
+      related = func(names []string) ([]string, error) {
+        var err error
+        references, err = getActionReferences(names)
+        return getActionReferenceItems(names, references), err
+      }
     }
 
-    fmt.Fprintf(color.Output,
-      wski18n.T("{{.ok}} deleted action {{.name}}\n",
-        map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(qName.entityName)}))
+    if isNamePattern(qName.entityName) {
+      return deleteEntityPattern("action", qName.entityName, related,
+        func(name string) error {
+          return deleteAction(QualifiedName{namespace: qName.namespace, entityName: name}, references[name])
+        },
+        getActionDeletedMessage)
+    }
+
+    if related != nil {
+      items, err := related([]string{qName.entityName})
+      if err != nil {
+        return err
+      }
+      if err = confirmDelete(append([]string{"action " + qName.entityName}, items...)); err != nil {
+        return err
+      }
+    }
+
+    if err = deleteAction(qName, references[qName.entityName]); err != nil {
+      return err
+    }
+
+    fmt.Fprintf(color.Output, getActionDeletedMessage(qName.entityName))
     return nil
   },
 }
 
+// deleteAction deletes an action, first deleting the rules and APIs that refer to it when there are any
+func deleteAction(qName QualifiedName, references *ActionReferences) error {
+  if references != nil {
+    if err := deleteActionReferences(references); err != nil {
+      return err
+    }
+  }
+
+  _, err := client.Actions.Delete(qName.entityName)
+  if err != nil {
+    whisk.Debug(whisk.DbgError, "client.Actions.Delete(%s) error: %s\n", qName.entityName, err)
+    errMsg := wski18n.T("Unable to delete action: {{.err}}", map[string]interface{}{"err": err})
+    whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+      whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    return whiskErr
+  }
+
+  return nil
+}
+
+func getActionDeletedMessage(actionName string) string {
+  return wski18n.T("{{.ok}} deleted action {{.name}}\n",
+    map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(actionName)})
+}
+
 var actionListCmd = &cobra.Command{
   Use:           "list [NAMESPACE]",
   Short:         wski18n.T("list all actions"),
@@ -443,6 +515,17 @@ func parseAction(cmd *cobra.Command, args []string) (*whisk.Action, error) {
     action.Exec = new(whisk.Exec)
     action.Exec.Kind = "sequence"
     action.Exec.Components = csvToQualifiedActions(artifact)
+  } else if artifact != "" && flags.action.kind == "go" {
+    code, err = buildGoAction(artifact)
+    if err != nil {
+      whisk.Debug(whisk.DbgError, "buildGoAction(%s) error: %s\n", artifact, err)
+      return nil, err
+    }
+
+    action.Exec = new(whisk.Exec)
+    action.Exec.Kind = "blackbox"
+    action.Exec.Image = "openwhisk/dockerskeleton"
+    action.Exec.Code = &code
   } else if artifact != "" {
     ext := filepath.Ext(artifact)
     action.Exec = new(whisk.Exec)
@@ -566,11 +649,310 @@ func getLimits() (*whisk.Limits) {
 // Flags //
 ///////////
 
+// BatchResult is the outcome of one invocation of a batch, written as one NDJSON line per input line
+type BatchResult struct {
+  Line          int         `json:"line"`
+  ActivationID  string      `json:"activationId,omitempty"`
+  Status        string      `json:"status,omitempty"`
+  Success       bool        `json:"success"`
+  Result        interface{} `json:"result,omitempty"`
+  Error         string      `json:"error,omitempty"`
+}
+
+// invokeActionBatch invokes the action once per line of the NDJSON file, merging each line into the given
+// parameters.  Results are written in input order; at most flags.action.concurrency invocations are in flight
+// or waiting to be written at any time.
+func invokeActionBatch(qName QualifiedName, parameters interface{}, filename string) error {
+  var input io.Reader = os.Stdin
+  var succeeded, failed int
+
+  if filename != "-" {
+    file, err := os.Open(filename)
+    if err != nil {
+      whisk.Debug(whisk.DbgError, "os.Open(%s) error: %s\n", filename, err)
+      errMsg := wski18n.T("File '{{.name}}' is not a valid file or it does not exist: {{.err}}",
+        map[string]interface{}{"name": filename, "err": err})
+      whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_USAGE,
+        whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
+      return whiskErr
+    }
+    defer file.Close()
+    input = file
+  }
+
+  slots := make(chan bool, max(flags.action.concurrency, 1))
+  pending := make(chan chan BatchResult, max(flags.action.concurrency, 1))
+  done := make(chan bool)
+  stop := false
+  var stopLock sync.Mutex
+
+  // Write the results in input order as they become available
+  go func() {
+    encoder := json.NewEncoder(os.Stdout)
+    for resultChan := range pending {
+      result := <-resultChan
+      encoder.Encode(result)
+      <-slots
+
+      if result.Success {
+        succeeded++
+      } else {
+        failed++
+        if flags.action.failFast {
+          stopLock.Lock()
+          stop = true
+          stopLock.Unlock()
+        }
+      }
+    }
+    done <- true
+  }()
+
+  reader := bufio.NewReader(input)
+  for line := 1; ; line++ {
+    stopLock.Lock()
+    stopped := stop
+    stopLock.Unlock()
+    if stopped {
+      break
+    }
+
+    data, err := reader.ReadBytes('\n')
+    if len(strings.TrimSpace(string(data))) > 0 {
+      resultChan := make(chan BatchResult, 1)
+      slots <- true
+      pending <- resultChan
+
+      go func(line int, data []byte) {
+        resultChan <- invokeBatchLine(qName, parameters, line, data)
+      }(line, data)
+    }
+
+    if err != nil {
+      if err != io.EOF {
+        whisk.Debug(whisk.DbgError, "reader.ReadBytes() error: %s\n", err)
+      }
+      break
+    }
+  }
+  close(pending)
+  <-done
+
+  fmt.Fprintf(colorable.NewColorableStderr(),
+    wski18n.T("{{.ok}} invoked /{{.namespace}}/{{.name}} {{.count}} times: {{.succeeded}} succeeded, {{.failed}} failed\n",
+      map[string]interface{}{
+        "ok": color.GreenString("ok:"),
+        "namespace": boldString(qName.namespace),
+        "name": boldString(qName.entityName),
+        "count": succeeded + failed,
+        "succeeded": succeeded,
+        "failed": failed}))
+
+  if failed > 0 {
+    errMsg := wski18n.T("{{.count}} invocations of action '{{.name}}' failed",
+      map[string]interface{}{"count": failed, "name": qName.entityName})
+    whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+      whisk.NO_DISPLAY_USAGE)
+    return whiskErr
+  }
+
+  return nil
+}
+
+func invokeBatchLine(qName QualifiedName, parameters interface{}, line int, data []byte) BatchResult {
+  var payload map[string]interface{}
+  result := BatchResult{Line: line}
+
+  if err := json.Unmarshal(data, &payload); err != nil {
+    result.Error = wski18n.T("Invalid JSON object: {{.err}}", map[string]interface{}{"err": err})
+    return result
+  }
+
+  // A null line decodes without error but leaves no object to invoke with
+  if payload == nil {
+    result.Error = wski18n.T("Invalid JSON object: the line is not a JSON object")
+    return result
+  }
+
+  // Values from the input line take precedence over the --param values
+  if defaults, ok := parameters.(map[string]interface{}); ok {
+    for key, value := range defaults {
+      if _, exists := payload[key]; !exists {
+        payload[key] = value
+      }
+    }
+  }
+
+  res, _, err := client.Actions.Invoke(qName.entityName, payload, true, false)
+  if err != nil {
+    result.Error = err.Error()
+  }
+
+  if activationID, ok := res["activationId"].(string); ok {
+    result.ActivationID = activationID
+  }
+  if response, ok := res["response"].(map[string]interface{}); ok {
+    result.Status, _ = response["status"].(string)
+    result.Success, _ = response["success"].(bool)
+    result.Result = response["result"]
+  }
+
+  if len(result.Error) > 0 {
+    result.Success = false
+  }
+
+  return result
+}
+
+// ActionReferences are the rules that invoke an action and the API operations that call it
+type ActionReferences struct {
+  Rules       []string
+  Operations  []*whisk.ApiOptions
+}
+
+/*
+Find the rules and API operations that refer to the named actions of the current namespace.  Every rule and API is
+read once for all of the actions; the namespace of the client is left alone, so that the actions can then be
+deleted concurrently.
+*/
+func getActionReferences(names []string) (map[string]*ActionReferences, error) {
+  references := make(map[string]*ActionReferences)
+  for _, name := range names {
+    references[name] = new(ActionReferences)
+  }
+
+  // Rules and APIs only refer to actions of their own namespace by name
+  getReferences := func(name string) *ActionReferences {
+    parts := strings.SplitN(strings.TrimPrefix(name, "/"), "/", 2)
+    if len(parts) < 2 {
+      return nil
+    }
+    return references[parts[1]]
+  }
+
+  ruleNames, err := listEntityNames("rule")
+  if err != nil {
+    whisk.Debug(whisk.DbgError, "listEntityNames(rule) error: %s\n", err)
+    errMsg := wski18n.T("Unable to obtain the list of entities for namespace '{{.namespace}}': {{.err}}",
+      map[string]interface{}{"namespace": getClientNamespace(), "err": err})
+    whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_NETWORK,
+      whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    return nil, whiskErr
+  }
+
+  for _, ruleName := range ruleNames {
+    rule, _, err := client.Rules.Get(ruleName)
+    if err != nil {
+      whisk.Debug(whisk.DbgError, "client.Rules.Get(%s) error: %s\n", ruleName, err)
+      errMsg := wski18n.T("Unable to get rule '{{.name}}': {{.err}}",
+        map[string]interface{}{"name": ruleName, "err": err})
+      whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_NETWORK,
+        whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+      return nil, whiskErr
+    }
+    if actionReferences := getReferences(getRuleEntityName(rule.Action, rule.Namespace)); actionReferences != nil {
+      actionReferences.Rules = append(actionReferences.Rules, rule.Name)
+    }
+  }
+
+  // The API gateway is optional, so there may be no APIs to look at
+  apis, err := getAllApis()
+  if err != nil {
+    whisk.Debug(whisk.DbgWarn, "getAllApis() error: %s\n", err)
+    fmt.Fprintf(colorable.NewColorableStderr(),
+      wski18n.T("{{.warning}} APIs are not deleted along with the actions: {{.err}}\n",
+        map[string]interface{}{"warning": color.YellowString("warning:"), "err": err}))
+  }
+  for _, api := range apis {
+    for relpath, verbs := range api.Swagger.Paths {
+      for verb, operation := range verbs {
+        ext := operation["x-ibm-op-ext"]
+        if ext == nil {
+          continue
+        }
+        name, _ := ext["actionName"].(string)
+        namespace, _ := ext["actionNamespace"].(string)
+        if actionReferences := getReferences(getQualifiedName(name, namespace)); actionReferences != nil {
+          actionReferences.Operations = append(actionReferences.Operations, &whisk.ApiOptions{
+            ApiBasePath: api.Swagger.BasePath,
+            ApiRelPath: relpath,
+            ApiVerb: strings.ToUpper(verb),
+            Force: true,
+          })
+        }
+      }
+    }
+  }
+
+  return references, nil
+}
+
+// getActionReferenceItems lists the references of the actions for the confirmation of their deletion
+func getActionReferenceItems(names []string, references map[string]*ActionReferences) []string {
+  var items []string
+
+  for _, name := range names {
+    if actionReferences := references[name]; actionReferences != nil {
+      for _, rule := range actionReferences.Rules {
+        items = append(items, "rule " + rule)
+      }
+      for _, operation := range actionReferences.Operations {
+        items = append(items,
+          fmt.Sprintf("API %s %s%s", operation.ApiVerb, operation.ApiBasePath, operation.ApiRelPath))
+      }
+    }
+  }
+
+  return items
+}
+
+// deleteActionReferences deletes the rules that invoke an action and the API operations that call it
+func deleteActionReferences(references *ActionReferences) error {
+  var err error
+
+  // Active rules cannot be deleted
+  for _, rule := range references.Rules {
+    if _, _, err = client.Rules.SetState(rule, "inactive"); err == nil {
+      _, err = client.Rules.Delete(rule)
+    }
+    if err != nil {
+      whisk.Debug(whisk.DbgError, "Delete of rule '%s' error: %s\n", rule, err)
+      errMsg := wski18n.T("Unable to delete rule '{{.name}}': {{.err}}",
+        map[string]interface{}{"name": rule, "err": err})
+      whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_NETWORK,
+        whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+      return whiskErr
+    }
+    fmt.Fprintf(color.Output,
+      wski18n.T("{{.ok}} deleted rule {{.name}}\n",
+        map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(rule)}))
+  }
+
+  for _, operation := range references.Operations {
+    if _, err = client.Apis.Delete(new(whisk.Api), operation); err != nil {
+      whisk.Debug(whisk.DbgError, "client.Apis.Delete(%#v) error: %s\n", operation, err)
+      errMsg := wski18n.T("Unable to delete API: {{.err}}", map[string]interface{}{"err": err})
+      whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_NETWORK,
+        whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+      return whiskErr
+    }
+    fmt.Fprintf(color.Output,
+      wski18n.T("{{.ok}} deleted {{.path}} {{.verb}} from {{.basepath}}\n",
+        map[string]interface{}{
+          "ok": color.GreenString("ok:"),
+          "path": operation.ApiRelPath,
+          "verb": operation.ApiVerb,
+          "basepath": operation.ApiBasePath}))
+  }
+
+  return nil
+}
+
 func init() {
   actionCreateCmd.Flags().BoolVar(&flags.action.docker, "docker", false, wski18n.T("treat ACTION as docker image path on dockerhub"))
   actionCreateCmd.Flags().BoolVar(&flags.action.copy, "copy", false, wski18n.T("treat ACTION as the name of an existing action"))
   actionCreateCmd.Flags().BoolVar(&flags.action.sequence, "sequence", false, wski18n.T("treat ACTION as comma separated sequence of actions to invoke"))
-  actionCreateCmd.Flags().StringVar(&flags.action.kind, "kind", "", wski18n.T("the `KIND` of the action runtime (example: swift:3, nodejs:6)"))
+  actionCreateCmd.Flags().StringVar(&flags.action.kind, "kind", "", wski18n.T("the `KIND` of the action runtime (example: swift:3, nodejs:6, go); go requires github.com/go-whisk/actionproxy on your GOPATH"))
   actionCreateCmd.Flags().StringVar(&flags.action.main, "main", "", wski18n.T("the name of the action entry point (function or fully-qualified method name when applicable)"))
   actionCreateCmd.Flags().StringVar(&flags.action.shared, "shared", "no", wski18n.T("action visibility `SCOPE`; yes = shared, no = private"))
   actionCreateCmd.Flags().IntVarP(&flags.action.timeout, "timeout", "t", TIMEOUT_LIMIT, wski18n.T("the timeout `LIMIT` in milliseconds after which the action is terminated"))
@@ -584,7 +966,7 @@ func init() {
   actionUpdateCmd.Flags().BoolVar(&flags.action.docker, "docker", false, wski18n.T("treat ACTION as docker image path on dockerhub"))
   actionUpdateCmd.Flags().BoolVar(&flags.action.copy, "copy", false, wski18n.T("treat ACTION as the name of an existing action"))
   actionUpdateCmd.Flags().BoolVar(&flags.action.sequence, "sequence", false, wski18n.T("treat ACTION as comma separated sequence of actions to invoke"))
-  actionUpdateCmd.Flags().StringVar(&flags.action.kind, "kind", "", wski18n.T("the `KIND` of the action runtime (example: swift:3, nodejs:6)"))
+  actionUpdateCmd.Flags().StringVar(&flags.action.kind, "kind", "", wski18n.T("the `KIND` of the action runtime (example: swift:3, nodejs:6, go); go requires github.com/go-whisk/actionproxy on your GOPATH"))
   actionUpdateCmd.Flags().StringVar(&flags.action.main, "main", "", wski18n.T("the name of the action entry point (function or fully-qualified method name when applicable)"))
   actionUpdateCmd.Flags().StringVar(&flags.action.shared, "shared", "", wski18n.T("action visibility `SCOPE`; yes = shared, no = private"))
   actionUpdateCmd.Flags().IntVarP(&flags.action.timeout, "timeout", "t", TIMEOUT_LIMIT, wski18n.T("the timeout `LIMIT` in milliseconds after which the action is terminated"))
@@ -594,14 +976,27 @@ func init() {
   actionUpdateCmd.Flags().StringVarP(&flags.common.annotFile, "annotation-file", "A", "", wski18n.T("`FILE` containing annotation values in JSON format"))
   actionUpdateCmd.Flags().StringSliceVarP(&flags.common.param, "param", "p", []string{}, wski18n.T("parameter values in `KEY VALUE` format"))
   actionUpdateCmd.Flags().StringVarP(&flags.common.paramFile, "param-file", "P", "", wski18n.T("`FILE` containing parameter values in JSON format"))
+  actionUpdateCmd.Flags().StringVar(&flags.common.ifVersion, "if-version", "", wski18n.T("update only if the action is still at `VERSION`, as shown by get"))
+  actionUpdateCmd.Flags().BoolVar(&flags.common.paramMerge, "param-merge", false, wski18n.T("merge the parameters into those of the existing action instead of replacing them"))
+  actionUpdateCmd.Flags().StringSliceVar(&flags.common.paramRemove, "param-remove", []string{}, wski18n.T("remove the parameter `KEY` from the existing action"))
+  actionUpdateCmd.Flags().BoolVar(&flags.common.annotMerge, "annotation-merge", false, wski18n.T("merge the annotations into those of the existing action instead of replacing them"))
+  actionUpdateCmd.Flags().StringSliceVar(&flags.common.annotRemove, "annotation-remove", []string{}, wski18n.T("remove the annotation `KEY` from the existing action"))
 
   actionInvokeCmd.Flags().StringSliceVarP(&flags.common.param, "param", "p", []string{}, wski18n.T("parameter values in `KEY VALUE` format"))
   actionInvokeCmd.Flags().StringVarP(&flags.common.paramFile, "param-file", "P", "", wski18n.T("`FILE` containing parameter values in JSON format"))
   actionInvokeCmd.Flags().BoolVarP(&flags.common.blocking, "blocking", "b", false, wski18n.T("blocking invoke"))
   actionInvokeCmd.Flags().BoolVarP(&flags.action.result, "result", "r", false, wski18n.T("show only activation result if a blocking activation (unless there is a failure)"))
+  actionInvokeCmd.Flags().StringVar(&flags.action.batch, "batch", "", wski18n.T("invoke the action once for each JSON object in the NDJSON `FILE`; use - to read from standard input"))
+  actionInvokeCmd.Flags().IntVarP(&flags.action.concurrency, "concurrency", "c", 10, wski18n.T("number of `WORKERS` invoking the action concurrently"))
+  actionInvokeCmd.Flags().BoolVar(&flags.action.failFast, "fail-fast", false, wski18n.T("stop invoking the batch after the first failure"))
 
   actionGetCmd.Flags().BoolVarP(&flags.common.summary, "summary", "s", false, wski18n.T("summarize action details"))
 
+  actionDeleteCmd.Flags().BoolVar(&flags.action.cascade, "cascade", false, wski18n.T("also delete the rules and APIs that refer to the action"))
+  actionDeleteCmd.Flags().BoolVarP(&flags.common.yes, "yes", "y", false, wski18n.T("delete without asking for confirmation"))
+  actionDeleteCmd.Flags().BoolVar(&flags.bulk.regex, "regex", false, wski18n.T("treat ACTION_NAME as a regular expression matching the actions to delete"))
+  actionDeleteCmd.Flags().IntVarP(&flags.bulk.concurrency, "concurrency", "c", 10, wski18n.T("number of actions deleted at a time when ACTION_NAME is a pattern"))
+
   actionListCmd.Flags().IntVarP(&flags.common.skip, "skip", "s", 0, wski18n.T("exclude the first `SKIP` number of actions from the result"))
   actionListCmd.Flags().IntVarP(&flags.common.limit, "limit", "l", 30, wski18n.T("only return `LIMIT` number of actions from the collection"))
 
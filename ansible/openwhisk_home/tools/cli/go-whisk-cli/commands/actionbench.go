@@ -0,0 +1,413 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+  "encoding/json"
+  "errors"
+  "fmt"
+  "io/ioutil"
+  "math"
+  "sort"
+  "strconv"
+  "strings"
+  "sync"
+  "time"
+
+  "../../go-whisk/whisk"
+  "../wski18n"
+
+  "github.com/fatih/color"
+  "github.com/spf13/cobra"
+)
+
+// Upper bounds, in milliseconds, of the latency histogram buckets
+var benchHistogramBounds = []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000}
+
+var actionBenchCmd = &cobra.Command{
+  Use:           "bench ACTION_NAME",
+  Short:         wski18n.T("measure action performance under concurrent load"),
+  SilenceUsage:  true,
+  SilenceErrors: true,
+  PreRunE:       setupClientConfig,
+  RunE: func(cmd *cobra.Command, args []string) error {
+    var parameters interface{}
+    var interval time.Duration
+
+    if whiskErr := checkArgs(args, 1, 1, "Action bench", wski18n.T("An action name is required.")); whiskErr != nil {
+      return whiskErr
+    }
+
+    qName, err := parseQualifiedName(args[0])
+    if err != nil {
+      whisk.Debug(whisk.DbgError, "parseQualifiedName(%s) failed: %s\n", args[0], err)
+      errMsg := wski18n.T("'{{.name}}' is not a valid qualified name: {{.err}}",
+        map[string]interface{}{"name": args[0], "err": err})
+      whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+        whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+      return whiskErr
+    }
+    client.Namespace = qName.namespace
+
+    if flags.action.concurrency < 1 || flags.action.requests < 1 {
+      errMsg := wski18n.T("The concurrency and number of requests must be greater than zero.")
+      whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+        whisk.DISPLAY_USAGE)
+      return whiskErr
+    }
+
+    if len(flags.action.rate) > 0 {
+      if interval, err = parseRate(flags.action.rate); err != nil {
+        return err
+      }
+    }
+
+    if len(flags.common.param) > 0 {
+      whisk.Debug(whisk.DbgInfo, "Parsing parameters: %#v\n", flags.common.param)
+
+      parameters, err = getJSONFromStrings(flags.common.param, false)
+      if err != nil {
+        whisk.Debug(whisk.DbgError, "getJSONFromStrings(%#v, false) failed: %s\n", flags.common.param, err)
+        errMsg := wski18n.T("Invalid parameter argument '{{.param}}': {{.err}}",
+            map[string]interface{}{"param": fmt.Sprintf("%#v", flags.common.param), "err": err})
+        whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+          whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
+        return whiskErr
+      }
+    }
+
+    fmt.Fprintf(color.Output,
+      wski18n.T("Invoking {{.name}} {{.requests}} times with a concurrency of {{.concurrency}}\n",
+        map[string]interface{}{
+          "name": boldString(qName.String()),
+          "requests": flags.action.requests,
+          "concurrency": flags.action.concurrency}))
+
+    start := time.Now()
+    samples := runBench(qName.entityName, parameters, flags.action.requests, flags.action.concurrency, interval)
+    report := newBenchReport(qName.String(), samples, time.Since(start))
+
+    printBenchReport(report)
+
+    if len(flags.action.out) > 0 {
+      data, err := json.MarshalIndent(report, "", "    ")
+      if err == nil {
+        err = ioutil.WriteFile(flags.action.out, data, 0644)
+      }
+      if err != nil {
+        whisk.Debug(whisk.DbgError, "Unable to write bench report to '%s': %s\n", flags.action.out, err)
+        errMsg := wski18n.T("Unable to write '{{.name}}': {{.err}}",
+          map[string]interface{}{"name": flags.action.out, "err": err})
+        whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+          whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return whiskErr
+      }
+    }
+
+    return nil
+  },
+}
+
+// BenchSample is the outcome of a single invocation.  Duration and WaitTime are reported by the server and are
+// negative when the activation record does not contain them.
+type BenchSample struct {
+  Latency   time.Duration
+  Duration  float64
+  WaitTime  float64
+  Error     string
+}
+
+type BenchStats struct {
+  Count int     `json:"count"`
+  Min   float64 `json:"min"`
+  Mean  float64 `json:"mean"`
+  P50   float64 `json:"p50"`
+  P90   float64 `json:"p90"`
+  P99   float64 `json:"p99"`
+  Max   float64 `json:"max"`
+}
+
+type BenchBucket struct {
+  UpperBound  float64 `json:"le"`   // +Inf is reported as 0
+  Count       int     `json:"count"`
+}
+
+// BenchReport summarizes a benchmark run; all times are in milliseconds
+type BenchReport struct {
+  Action      string          `json:"action"`
+  Requests    int             `json:"requests"`
+  Concurrency int             `json:"concurrency"`
+  Rate        string          `json:"rate,omitempty"`
+  Elapsed     float64         `json:"elapsed"`
+  Throughput  float64         `json:"throughput"`   // invocations per second
+  Succeeded   int             `json:"succeeded"`
+  Failed      int             `json:"failed"`
+  Errors      map[string]int  `json:"errors"`
+  Latency     BenchStats      `json:"latency"`
+  Duration    BenchStats      `json:"duration"`
+  WaitTime    BenchStats      `json:"waitTime"`
+  Histogram   []BenchBucket   `json:"histogram"`
+}
+
+// parseRate converts a rate such as "200/s" or "600/min" into the interval between two invocations
+func parseRate(rate string) (time.Duration, error) {
+  var unit time.Duration = time.Second
+
+  parts := strings.SplitN(rate, "/", 2)
+  if len(parts) == 2 {
+    switch strings.ToLower(parts[1]) {
+    case "s", "sec", "second":
+      unit = time.Second
+    case "m", "min", "minute":
+      unit = time.Minute
+    case "h", "hour":
+      unit = time.Hour
+    default:
+      unit = 0
+    }
+  }
+
+  count, err := strconv.ParseFloat(parts[0], 64)
+  if err != nil || count <= 0 || unit == 0 {
+    whisk.Debug(whisk.DbgError, "Invalid rate '%s'\n", rate)
+    errMsg := wski18n.T("'{{.rate}}' is not a valid rate; use a value such as 200/s or 600/min",
+      map[string]interface{}{"rate": rate})
+    whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+      whisk.DISPLAY_USAGE)
+    return 0, whiskErr
+  }
+
+  return time.Duration(float64(unit) / count), nil
+}
+
+// runBench invokes the action requests times from a pool of concurrency workers.  When interval is not zero,
+// invocations are started no faster than one per interval.
+func runBench(name string, parameters interface{}, requests int, concurrency int,
+  interval time.Duration) []BenchSample {
+  samples := make([]BenchSample, requests)
+  jobs := make(chan int)
+  var wg sync.WaitGroup
+
+  for i := 0; i < concurrency; i++ {
+    wg.Add(1)
+    go func() {
+      defer wg.Done()
+      for job := range jobs {
+        samples[job] = benchInvoke(name, parameters)
+      }
+    }()
+  }
+
+  var ticker *time.Ticker
+  if interval > 0 {
+    ticker = time.NewTicker(interval)
+    defer ticker.Stop()
+  }
+
+  for i := 0; i < requests; i++ {
+    if ticker != nil && i > 0 {
+      <-ticker.C
+    }
+    jobs <- i
+  }
+  close(jobs)
+  wg.Wait()
+
+  return samples
+}
+
+func benchInvoke(name string, parameters interface{}) BenchSample {
+  start := time.Now()
+  res, _, err := client.Actions.Invoke(name, parameters, true, false)
+  sample := BenchSample{Latency: time.Since(start), Duration: -1, WaitTime: -1}
+
+  if err != nil {
+    sample.Error = getErrorClass(err)
+  }
+
+  if duration, ok := getNumber(res["duration"]); ok {
+    sample.Duration = duration
+  }
+  if annotations, ok := res["annotations"].([]interface{}); ok {
+    for _, annotation := range annotations {
+      if kv, ok := annotation.(map[string]interface{}); ok && kv["key"] == "waitTime" {
+        if waitTime, ok := getNumber(kv["value"]); ok {
+          sample.WaitTime = waitTime
+        }
+      }
+    }
+  }
+
+  return sample
+}
+
+// getErrorClass names the kind of failure an invocation error represents
+func getErrorClass(err error) string {
+  whiskErr, isWhiskErr := err.(*whisk.WskError)
+
+  switch {
+  case !isWhiskErr:
+    return "other"
+  case whiskErr.ApplicationError:
+    return "application error"
+  case whiskErr.ExitCode == whisk.EXITCODE_ERR_NETWORK:
+    return "network error"
+  case whiskErr.ExitCode < 0:
+    // HTTP failures are reported with an exit code of the HTTP status code - 256
+    return fmt.Sprintf("HTTP %d", whiskErr.ExitCode + 256)
+  }
+
+  return "other"
+}
+
+func newBenchReport(name string, samples []BenchSample, elapsed time.Duration) *BenchReport {
+  var latencies, durations, waitTimes []float64
+
+  report := &BenchReport{
+    Action: name,
+    Requests: len(samples),
+    Concurrency: flags.action.concurrency,
+    Rate: flags.action.rate,
+    Elapsed: float64(elapsed) / float64(time.Millisecond),
+    Throughput: float64(len(samples)) / elapsed.Seconds(),
+    Errors: make(map[string]int),
+  }
+
+  for _, bound := range benchHistogramBounds {
+    report.Histogram = append(report.Histogram, BenchBucket{UpperBound: bound})
+  }
+  report.Histogram = append(report.Histogram, BenchBucket{})
+
+  for _, sample := range samples {
+    latency := float64(sample.Latency) / float64(time.Millisecond)
+    latencies = append(latencies, latency)
+
+    bucket := sort.SearchFloat64s(benchHistogramBounds, latency)
+    report.Histogram[bucket].Count++
+
+    if len(sample.Error) > 0 {
+      report.Failed++
+      report.Errors[sample.Error]++
+    } else {
+      report.Succeeded++
+    }
+
+    if sample.Duration >= 0 {
+      durations = append(durations, sample.Duration)
+    }
+    if sample.WaitTime >= 0 {
+      waitTimes = append(waitTimes, sample.WaitTime)
+    }
+  }
+
+  report.Latency = getBenchStats(latencies)
+  report.Duration = getBenchStats(durations)
+  report.WaitTime = getBenchStats(waitTimes)
+
+  return report
+}
+
+func getBenchStats(values []float64) BenchStats {
+  var sum float64
+
+  if len(values) == 0 {
+    return BenchStats{}
+  }
+
+  sorted := append([]float64{}, values...)
+  sort.Float64s(sorted)
+
+  for _, value := range sorted {
+    sum += value
+  }
+
+  percentile := func(p float64) float64 {
+    index := int(math.Ceil(p * float64(len(sorted)))) - 1
+    return sorted[int(math.Max(0, float64(index)))]
+  }
+
+  return BenchStats{
+    Count: len(sorted),
+    Min: sorted[0],
+    Mean: sum / float64(len(sorted)),
+    P50: percentile(0.50),
+    P90: percentile(0.90),
+    P99: percentile(0.99),
+    Max: sorted[len(sorted) - 1],
+  }
+}
+
+func printBenchReport(report *BenchReport) {
+  fmt.Fprintf(color.Output, "\n%s\n", boldString(wski18n.T("Results")))
+  fmt.Fprintf(color.Output, wski18n.T("  elapsed:     {{.elapsed}} ms\n",
+    map[string]interface{}{"elapsed": formatMillis(report.Elapsed)}))
+  fmt.Fprintf(color.Output, wski18n.T("  throughput:  {{.throughput}} invocations/s\n",
+    map[string]interface{}{"throughput": strconv.FormatFloat(report.Throughput, 'f', 2, 64)}))
+  fmt.Fprintf(color.Output, wski18n.T("  succeeded:   {{.count}}\n", map[string]interface{}{"count": report.Succeeded}))
+  fmt.Fprintf(color.Output, wski18n.T("  failed:      {{.count}}\n", map[string]interface{}{"count": report.Failed}))
+
+  var classes []string
+  for class := range report.Errors {
+    classes = append(classes, class)
+  }
+  sort.Strings(classes)
+  for _, class := range classes {
+    fmt.Fprintf(color.Output, "    %-20s %d\n", class, report.Errors[class])
+  }
+
+  fmt.Fprintf(color.Output, "\n%s\n", boldString(wski18n.T("Timings (ms)")))
+  fmt.Fprintf(color.Output, "  %-10s %8s %10s %10s %10s %10s %10s %10s\n", "", "count", "min", "mean", "p50", "p90",
+    "p99", "max")
+  printBenchStats(wski18n.T("latency"), report.Latency)
+  printBenchStats(wski18n.T("duration"), report.Duration)
+  printBenchStats(wski18n.T("wait time"), report.WaitTime)
+
+  fmt.Fprintf(color.Output, "\n%s\n", boldString(wski18n.T("Latency histogram (ms)")))
+  for _, bucket := range report.Histogram {
+    bound := "+Inf"
+    if bucket.UpperBound > 0 {
+      bound = formatMillis(bucket.UpperBound)
+    }
+
+    bar := ""
+    if report.Requests > 0 {
+      bar = strings.Repeat("#", bucket.Count * 50 / report.Requests)
+    }
+    fmt.Fprintf(color.Output, "  <= %-8s %8d %s\n", bound, bucket.Count, bar)
+  }
+}
+
+func printBenchStats(label string, stats BenchStats) {
+  fmt.Fprintf(color.Output, "  %-10s %8d %10s %10s %10s %10s %10s %10s\n", label, stats.Count,
+    formatMillis(stats.Min), formatMillis(stats.Mean), formatMillis(stats.P50), formatMillis(stats.P90),
+    formatMillis(stats.P99), formatMillis(stats.Max))
+}
+
+func formatMillis(value float64) string {
+  return strconv.FormatFloat(value, 'f', 1, 64)
+}
+
+func init() {
+  actionBenchCmd.Flags().IntVarP(&flags.action.concurrency, "concurrency", "c", 10, wski18n.T("number of `WORKERS` invoking the action concurrently"))
+  actionBenchCmd.Flags().IntVarP(&flags.action.requests, "requests", "n", 100, wski18n.T("total `NUMBER` of invocations"))
+  actionBenchCmd.Flags().StringVar(&flags.action.rate, "rate", "", wski18n.T("maximum invocation `RATE`, e.g. 200/s or 600/min"))
+  actionBenchCmd.Flags().StringVarP(&flags.action.out, "out", "o", "", wski18n.T("write the results in JSON format to `FILE`"))
+  actionBenchCmd.Flags().StringSliceVarP(&flags.common.param, "param", "p", []string{}, wski18n.T("parameter values in `KEY VALUE` format"))
+  actionBenchCmd.Flags().StringVarP(&flags.common.paramFile, "param-file", "P", "", wski18n.T("`FILE` containing parameter values in JSON format"))
+
+  actionCmd.AddCommand(actionBenchCmd)
+}
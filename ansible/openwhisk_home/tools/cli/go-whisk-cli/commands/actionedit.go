@@ -0,0 +1,212 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+  "bytes"
+  "encoding/base64"
+  "errors"
+  "fmt"
+  "io/ioutil"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "strings"
+
+  "../../go-whisk/whisk"
+  "../wski18n"
+
+  "github.com/fatih/color"
+  "github.com/mattn/go-colorable"
+  "github.com/spf13/cobra"
+)
+
+// Source file extensions of the action kinds, so that the editor recognizes the language
+var actionCodeExtensions = map[string]string{
+  "nodejs": ".js",
+  "python": ".py",
+  "swift": ".swift",
+  "php": ".php",
+}
+
+var actionEditCmd = &cobra.Command{
+  Use:           "edit ACTION_NAME",
+  Short:         wski18n.T("edit the code of an action in $EDITOR and update the action"),
+  SilenceUsage:  true,
+  SilenceErrors: true,
+  PreRunE:       setupClientConfig,
+  RunE: func(cmd *cobra.Command, args []string) error {
+    if whiskErr := checkArgs(args, 1, 1, "Action edit", wski18n.T("An action name is required.")); whiskErr != nil {
+      return whiskErr
+    }
+
+    qName, err := parseQualifiedName(args[0])
+    if err != nil {
+      whisk.Debug(whisk.DbgError, "parseQualifiedName(%s) failed: %s\n", args[0], err)
+      errMsg := wski18n.T("'{{.name}}' is not a valid qualified name: {{.err}}",
+        map[string]interface{}{"name": args[0], "err": err})
+      whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+        whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
+      return whiskErr
+    }
+    client.Namespace = qName.namespace
+
+    action, _, err := client.Actions.Get(qName.entityName)
+    if err != nil {
+      whisk.Debug(whisk.DbgError, "client.Actions.Get(%s) error: %s\n", qName.entityName, err)
+      errMsg := wski18n.T("Unable to get action: {{.err}}", map[string]interface{}{"err": err})
+      whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_NETWORK,
+        whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+      return whiskErr
+    }
+
+    if err = checkActionEditable(action, qName.entityName); err != nil {
+      return err
+    }
+
+    dir, err := ioutil.TempDir("", "wskedit")
+    if err != nil {
+      return makeActionEditError(qName.entityName, err)
+    }
+
+    filename := filepath.Join(dir, filepath.Base(qName.entityName) + getActionCodeExtension(action.Exec.Kind))
+    edited, err := editActionCode(action, qName.entityName, filename)
+
+    // An update that fails after the editor ran keeps the edited code so that it is not lost
+    if err == nil || !edited {
+      os.RemoveAll(dir)
+    } else {
+      fmt.Fprintf(colorable.NewColorableStderr(), wski18n.T("The edited code is saved in {{.name}}\n",
+        map[string]interface{}{"name": filename}))
+    }
+    return err
+  },
+}
+
+/*
+Edit the code of an action in a file and update the action with it, unless the code is unchanged.  The update is
+based on the version of the action that was edited, so it is refused when someone else updated the action in the
+meantime.  The returned flag tells whether the editor ran, i.e. whether the file may hold edits.
+*/
+func editActionCode(action *whisk.Action, actionName string, filename string) (bool, error) {
+  if err := ioutil.WriteFile(filename, []byte(*action.Exec.Code), 0600); err != nil {
+    return false, makeActionEditError(actionName, err)
+  }
+
+  if err := runEditor(filename); err != nil {
+    return false, makeActionEditError(actionName, err)
+  }
+
+  code, err := ioutil.ReadFile(filename)
+  if err != nil {
+    return true, makeActionEditError(actionName, err)
+  }
+
+  if string(code) == *action.Exec.Code {
+    fmt.Fprintf(color.Output,
+      wski18n.T("{{.ok}} action {{.name}} is unchanged\n",
+        map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(actionName)}))
+    return true, nil
+  }
+
+  editedExec := *action.Exec
+  editedExec.Code = new(string)
+  *editedExec.Code = string(code)
+
+  edited := &whisk.Action{
+    Name: actionName,
+    Exec: &editedExec,
+    Annotations: action.Annotations,
+    Parameters: action.Parameters,
+    Limits: action.Limits,
+    Publish: action.Publish,
+  }
+
+  if err = checkActionPolicy(edited, true); err != nil {
+    return true, err
+  }
+
+  if _, _, err = client.Actions.InsertIfVersion(edited, action.Version); err != nil {
+    whisk.Debug(whisk.DbgError, "client.Actions.InsertIfVersion(%#v, %s) error: %s\n", edited, action.Version, err)
+    errMsg := wski18n.T("Unable to update action: {{.err}}", map[string]interface{}{"err": err})
+    whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_NETWORK,
+      whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    return true, whiskErr
+  }
+
+  fmt.Fprintf(color.Output,
+    wski18n.T("{{.ok}} updated action {{.name}}\n",
+      map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(actionName)}))
+  return true, nil
+}
+
+// checkActionEditable rejects actions without source code: sequences, docker images, jars, and zip archives
+func checkActionEditable(action *whisk.Action, actionName string) error {
+  var errMsg string
+
+  if action.Exec == nil || action.Exec.Code == nil || len(action.Exec.Components) > 0 {
+    errMsg = wski18n.T("The action {{.name}} has no code to edit.", map[string]interface{}{"name": actionName})
+  } else if archive, err := base64.StdEncoding.DecodeString(*action.Exec.Code);
+      err == nil && bytes.HasPrefix(archive, []byte("PK\x03\x04")) {
+    errMsg = wski18n.T("The action {{.name}} is a zip archive and cannot be edited.",
+      map[string]interface{}{"name": actionName})
+  } else {
+    return nil
+  }
+
+  whisk.Debug(whisk.DbgError, "Action '%s' cannot be edited\n", actionName)
+  whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+    whisk.NO_DISPLAY_USAGE)
+  return whiskErr
+}
+
+func getActionCodeExtension(kind string) string {
+  return actionCodeExtensions[strings.SplitN(kind, ":", 2)[0]]
+}
+
+// runEditor opens a file in $VISUAL or $EDITOR, which may include arguments, and waits for the editor to exit
+func runEditor(filename string) error {
+  editor := os.Getenv("VISUAL")
+  if len(editor) == 0 {
+    editor = os.Getenv("EDITOR")
+  }
+  if len(strings.TrimSpace(editor)) == 0 {
+    editor = "vi"
+  }
+
+  fields := strings.Fields(editor)
+  edit := exec.Command(fields[0], append(fields[1:], filename)...)
+  edit.Stdin = os.Stdin
+  edit.Stdout = os.Stdout
+  edit.Stderr = os.Stderr
+
+  whisk.Debug(whisk.DbgInfo, "Running editor: %s %s\n", editor, filename)
+  return edit.Run()
+}
+
+func makeActionEditError(actionName string, err error) error {
+  whisk.Debug(whisk.DbgError, "Edit of action '%s' failed: %s\n", actionName, err)
+  errMsg := wski18n.T("Unable to edit action '{{.name}}': {{.err}}",
+    map[string]interface{}{"name": actionName, "err": err})
+  whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+    whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+  return whiskErr
+}
+
+func init() {
+  actionCmd.AddCommand(actionEditCmd)
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+  "archive/zip"
+  "bytes"
+  "encoding/base64"
+  "errors"
+  "io/ioutil"
+  "os"
+  "os/exec"
+  "path/filepath"
+
+  "../../go-whisk/whisk"
+  "../wski18n"
+)
+
+// buildGoAction cross-compiles the Go file or package directory for the invoker and returns the binary zipped
+// as the "exec" file of a blackbox action, base64 encoded.  The program is expected to use the actionproxy package,
+// which go build resolves from the user's GOPATH as github.com/go-whisk/actionproxy.
+func buildGoAction(artifact string) (string, error) {
+  info, err := os.Stat(artifact)
+  if err != nil {
+    whisk.Debug(whisk.DbgError, "os.Stat(%s) error: %s\n", artifact, err)
+    errMsg := wski18n.T("File '{{.name}}' is not a valid file or it does not exist: {{.err}}",
+      map[string]interface{}{"name": artifact, "err": err})
+    whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_USAGE,
+      whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
+    return "", whiskErr
+  }
+
+  dir, err := ioutil.TempDir("", "wskgo")
+  if err != nil {
+    whisk.Debug(whisk.DbgError, "ioutil.TempDir() error: %s\n", err)
+    errMsg := wski18n.T("Unable to build Go action '{{.name}}': {{.err}}",
+      map[string]interface{}{"name": artifact, "err": err})
+    whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+      whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    return "", whiskErr
+  }
+  defer os.RemoveAll(dir)
+
+  binary := filepath.Join(dir, "exec")
+  build := exec.Command("go", "build", "-o", binary)
+  if info.IsDir() {
+    build.Dir = artifact
+  } else {
+    build.Args = append(build.Args, filepath.Base(artifact))
+    build.Dir = filepath.Dir(artifact)
+  }
+  // The dockerskeleton image runs on linux/amd64 and has no C library to link against
+  build.Env = append(os.Environ(), "GOOS=linux", "GOARCH=amd64", "CGO_ENABLED=0")
+
+  if output, err := build.CombinedOutput(); err != nil {
+    whisk.Debug(whisk.DbgError, "go build %s error: %s\n%s\n", artifact, err, output)
+    errMsg := wski18n.T("Unable to build Go action '{{.name}}': {{.err}}",
+      map[string]interface{}{"name": artifact, "err": string(bytes.TrimSpace(output))})
+    whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+      whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    return "", whiskErr
+  }
+
+  archive, err := zipExecutable(binary)
+  if err != nil {
+    whisk.Debug(whisk.DbgError, "zipExecutable(%s) error: %s\n", binary, err)
+    errMsg := wski18n.T("Unable to build Go action '{{.name}}': {{.err}}",
+      map[string]interface{}{"name": artifact, "err": err})
+    whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+      whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    return "", whiskErr
+  }
+
+  return base64.StdEncoding.EncodeToString(archive), nil
+}
+
+// zipExecutable creates a zip archive holding the binary as an executable file named "exec"
+func zipExecutable(binary string) ([]byte, error) {
+  var buffer bytes.Buffer
+
+  data, err := ioutil.ReadFile(binary)
+  if err != nil {
+    return nil, err
+  }
+
+  writer := zip.NewWriter(&buffer)
+  header := &zip.FileHeader{Name: "exec", Method: zip.Deflate}
+  header.SetMode(0755)
+
+  file, err := writer.CreateHeader(header)
+  if err == nil {
+    _, err = file.Write(data)
+  }
+  if err == nil {
+    err = writer.Close()
+  }
+  if err != nil {
+    return nil, err
+  }
+
+  return buffer.Bytes(), nil
+}
@@ -0,0 +1,229 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+  "errors"
+  "fmt"
+  "net"
+  "net/http"
+  "net/url"
+  "os/exec"
+  "path/filepath"
+  "strconv"
+  "strings"
+  "time"
+
+  "../../go-whisk/whisk"
+  "../wski18n"
+
+  "github.com/fatih/color"
+  "github.com/mattn/go-colorable"
+  "github.com/spf13/cobra"
+)
+
+// Written by the action runtimes to stdout and stderr after each activation
+const ActivationLogSentinel = "XXX_THE_END_OF_A_WHISK_ACTIVATION_XXX"
+
+var actionRunLocalCmd = &cobra.Command{
+  Use:           "run-local ACTION",
+  Short:         wski18n.T("run an action in a locally started action runtime"),
+  SilenceUsage:  true,
+  SilenceErrors: true,
+  PreRunE:       setupLocalClientConfig,
+  RunE: func(cmd *cobra.Command, args []string) error {
+    if whiskErr := checkArgs(args, 1, 1, "Action run-local", wski18n.T("An action is required.")); whiskErr != nil {
+      return whiskErr
+    }
+
+    artifact := args[0]
+    ext := filepath.Ext(artifact)
+    name := strings.TrimSuffix(filepath.Base(artifact), ext)
+
+    action, err := parseAction(cmd, []string{name, artifact})
+    if err != nil {
+      whisk.Debug(whisk.DbgError, "parseAction(%s) error: %s\n", args, err)
+      errMsg := wski18n.T("Unable to parse action command arguments: {{.err}}", map[string]interface{}{"err": err})
+      whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+        whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
+      return whiskErr
+    }
+
+    runtimeURL, err := url.Parse(strings.TrimSuffix(flags.action.runtimeURL, "/") + "/")
+    if err != nil || len(runtimeURL.Host) == 0 {
+      whisk.Debug(whisk.DbgError, "url.Parse(%s) error: %s\n", flags.action.runtimeURL, err)
+      errMsg := wski18n.T("The runtime URL '{{.url}}' is not valid.", map[string]interface{}{"url": flags.action.runtimeURL})
+      whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+        whisk.DISPLAY_USAGE)
+      return whiskErr
+    }
+    runtime := whisk.NewRuntimeClient(http.DefaultClient, runtimeURL)
+    container := getLocalRuntimeContainer(runtimeURL)
+    start := time.Now()
+
+    binary := ext == ".zip" || action.Exec.Kind == "java" || flags.action.kind == "go"
+    if _, err = runtime.Init(action, binary); err != nil {
+      whisk.Debug(whisk.DbgError, "runtime.Init(%#v) error: %s\n", action, err)
+      errMsg := wski18n.T("Unable to initialize action '{{.name}}' in runtime '{{.url}}': {{.err}}",
+        map[string]interface{}{"name": name, "url": runtimeURL, "err": err})
+      whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+        whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+      printLocalLogs(container, start)
+      return whiskErr
+    }
+
+    parameters := make(map[string]interface{})
+    for _, parameter := range action.Parameters {
+      parameters[parameter.Key] = parameter.Value
+    }
+
+    timeout := TIMEOUT_LIMIT
+    if action.Limits != nil && action.Limits.Timeout != nil {
+      timeout = *action.Limits.Timeout
+    }
+
+    run := &whisk.RuntimeRun{
+      Value: parameters,
+      Namespace: action.Namespace,
+      ActionName: getQualifiedName(action.Name, action.Namespace),
+      ActivationId: "local",
+      Deadline: strconv.FormatInt(start.UnixNano() / int64(time.Millisecond) + int64(timeout), 10),
+      ApiKey: Properties.Auth,
+    }
+
+    res, _, err := runtime.Run(run)
+    outputStream := color.Output
+
+    if err != nil {
+      whiskErr, isWhiskErr := err.(*whisk.WskError)
+
+      if !isWhiskErr || !whiskErr.ApplicationError {
+        whisk.Debug(whisk.DbgError, "runtime.Run(%#v) error: %s\n", run, err)
+        errMsg := wski18n.T("Unable to run action '{{.name}}' in runtime '{{.url}}': {{.err}}",
+          map[string]interface{}{"name": name, "url": runtimeURL, "err": err})
+        whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+          whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        printLocalLogs(container, start)
+        return whiskErr
+      }
+      outputStream = colorable.NewColorableStderr()
+    } else {
+      fmt.Fprintf(color.Output,
+        wski18n.T("{{.ok}} ran action {{.name}} in {{.url}}\n",
+          map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(name), "url": runtimeURL}))
+    }
+
+    printJSON(res, outputStream)
+    printLocalLogs(container, start)
+
+    return err
+  },
+}
+
+// The runtime is reached directly, so the API host only needs to be set when an existing action is copied
+func setupLocalClientConfig(cmd *cobra.Command, args []string) error {
+  if len(Properties.APIHost) > 0 {
+    return setupClientConfig(cmd, args)
+  }
+
+  var err error
+  client, err = whisk.NewClient(http.DefaultClient, &whisk.Config{Namespace: Properties.Namespace})
+  if err != nil {
+    whisk.Debug(whisk.DbgError, "whisk.NewClient() error: %s\n", err)
+    errMsg := wski18n.T("Unable to initialize server connection: {{.err}}", map[string]interface{}{"err": err})
+    whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+      whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    return whiskErr
+  }
+
+  return nil
+}
+
+// getLocalRuntimeContainer names the docker container running the runtime: the --container flag, or else the one
+// container that publishes the port of the runtime URL.  It is empty when no such container is found.
+func getLocalRuntimeContainer(runtimeURL *url.URL) string {
+  if len(flags.action.container) > 0 {
+    return flags.action.container
+  }
+
+  _, port, err := net.SplitHostPort(runtimeURL.Host)
+  if err != nil {
+    port = "80"
+    if runtimeURL.Scheme == "https" {
+      port = "443"
+    }
+  }
+
+  output, err := exec.Command("docker", "ps", "--quiet", "--filter", "publish=" + port).Output()
+  if err != nil {
+    whisk.Debug(whisk.DbgError, "docker ps --filter publish=%s error: %s\n", port, err)
+    return ""
+  }
+
+  containers := strings.Fields(string(output))
+  if len(containers) != 1 {
+    whisk.Debug(whisk.DbgInfo, "Containers publishing port %s: %v\n", port, containers)
+    return ""
+  }
+
+  return containers[0]
+}
+
+// printLocalLogs prints what the runtime container logged since start.  The runtime does not return the logs
+// over HTTP, so they are read with docker.
+func printLocalLogs(container string, start time.Time) {
+  var logs []string
+
+  if len(container) == 0 {
+    fmt.Fprintf(colorable.NewColorableStderr(),
+      wski18n.T("{{.warning}} No docker container was found for the runtime; use --container to read the action logs\n",
+        map[string]interface{}{"warning": color.YellowString("warning:")}))
+    return
+  }
+
+  output, err := exec.Command("docker", "logs", "--timestamps", "--since", start.Format(time.RFC3339Nano),
+    container).CombinedOutput()
+  if err != nil {
+    whisk.Debug(whisk.DbgError, "docker logs %s error: %s\n", container, err)
+    fmt.Fprintf(colorable.NewColorableStderr(),
+      wski18n.T("Unable to read the logs of container '{{.name}}': {{.err}}\n",
+        map[string]interface{}{"name": container, "err": err}))
+    return
+  }
+
+  for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+    if len(line) > 0 && !strings.Contains(line, ActivationLogSentinel) {
+      logs = append(logs, line)
+    }
+  }
+
+  fmt.Fprintf(color.Output, "%s\n", boldString(wski18n.T("logs")))
+  printActivationLogs(logs)
+}
+
+func init() {
+  actionRunLocalCmd.Flags().StringVar(&flags.action.runtimeURL, "runtime-url", "http://localhost:8080", wski18n.T("`URL` of the locally started action runtime"))
+  actionRunLocalCmd.Flags().StringVar(&flags.action.container, "container", "", wski18n.T("docker `CONTAINER` running the runtime, used to read the action logs; by default, the container publishing the port of the runtime URL"))
+  actionRunLocalCmd.Flags().BoolVar(&flags.action.docker, "docker", false, wski18n.T("treat ACTION as docker image path on dockerhub"))
+  actionRunLocalCmd.Flags().StringVar(&flags.action.kind, "kind", "", wski18n.T("the `KIND` of the action runtime (example: swift:3, nodejs:6, go); go requires github.com/go-whisk/actionproxy on your GOPATH"))
+  actionRunLocalCmd.Flags().StringVar(&flags.action.main, "main", "", wski18n.T("the name of the action entry point (function or fully-qualified method name when applicable)"))
+  actionRunLocalCmd.Flags().IntVarP(&flags.action.timeout, "timeout", "t", TIMEOUT_LIMIT, wski18n.T("the timeout `LIMIT` in milliseconds after which the action is terminated"))
+  actionRunLocalCmd.Flags().StringSliceVarP(&flags.common.param, "param", "p", []string{}, wski18n.T("parameter values in `KEY VALUE` format"))
+  actionRunLocalCmd.Flags().StringVarP(&flags.common.paramFile, "param-file", "P", "", wski18n.T("`FILE` containing parameter values in JSON format"))
+
+  actionCmd.AddCommand(actionRunLocalCmd)
+}
@@ -17,10 +17,18 @@
 package commands
 
 import (
+    "encoding/csv"
+    "encoding/json"
     "errors"
     "fmt"
+    "io"
+    "io/ioutil"
     "os"
     "os/signal"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
     "syscall"
     "time"
 
@@ -77,15 +85,31 @@ var activationListCmd = &cobra.Command{
             return whiskErr
         }
 
+        since, upto, err := getActivationTimeRange()
+        if err != nil {
+            return err
+        }
+
+        filter, err := getActivationFilter()
+        if err != nil {
+            return err
+        }
+
         options := &whisk.ActivationListOptions{
             Name:  qName.entityName,
             Limit: flags.common.limit,
             Skip:  flags.common.skip,
-            Upto:  flags.activation.upto,
-            Since: flags.activation.since,
+            Upto:  upto,
+            Since: since,
             Docs:  flags.common.full,
         }
-        activations, _, err := client.Activations.List(options)
+
+        var activations []whisk.Activation
+        if filter.isClientSide() {
+            activations, err = listFilteredActivations(options, filter)
+        } else {
+            activations, _, err = client.Activations.List(options)
+        }
         if err != nil {
             whisk.Debug(whisk.DbgError, "client.Activations.List() error: %s\n", err)
             errStr := wski18n.T("Unable to obtain the list of activations for namespace '{{.name}}': {{.err}}",
@@ -96,7 +120,7 @@ var activationListCmd = &cobra.Command{
         }
 
         // When the --full (URL contains "?docs=true") option is specified, display the entire activation details
-        if options.Docs == true {
+        if flags.common.full {
             printFullActivationList(activations)
         } else {
             printList(activations)
@@ -255,7 +279,13 @@ var activationPollCmd = &cobra.Command{
         // Map used to track activation records already displayed to the console
         reported := make(map[string]bool)
 
-        if flags.activation.sinceSeconds+
+        if len(flags.activation.since) > 0 {
+            var err error
+
+            if pollSince, err = parseTimeExpression(flags.activation.since, time.Now()); err != nil {
+                return err
+            }
+        } else if flags.activation.sinceSeconds+
         flags.activation.sinceMinutes+
         flags.activation.sinceHours+
         flags.activation.sinceDays ==
@@ -338,26 +368,716 @@ var activationPollCmd = &cobra.Command{
     },
 }
 
+var activationUsageCmd = &cobra.Command{
+    Use:   "usage",
+    Short: wski18n.T("summarize resource usage and cost of activations"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if whiskErr := checkArgs(args, 0, 0, "Activation usage",
+                wski18n.T("No arguments are required.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        groupBy := strings.ToLower(flags.activation.groupBy)
+        if groupBy != "action" && groupBy != "package" && groupBy != "namespace" {
+            whisk.Debug(whisk.DbgError, "Invalid group by value '%s'\n", flags.activation.groupBy)
+            errMsg := wski18n.T("'{{.name}}' is not a valid grouping; use action, package or namespace",
+                map[string]interface{}{"name": flags.activation.groupBy})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        format := strings.ToLower(flags.activation.format)
+        if format != "csv" && format != "json" {
+            whisk.Debug(whisk.DbgError, "Invalid output format '%s'\n", flags.activation.format)
+            errMsg := wski18n.T("'{{.name}}' is not a valid output format; use csv or json",
+                map[string]interface{}{"name": flags.activation.format})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        since, upto, err := getActivationTimeRange()
+        if err != nil {
+            return err
+        }
+
+        options := &whisk.ActivationListOptions{
+            Since: since,
+            Upto:  upto,
+            Docs:  true,
+        }
+
+        usage := make(map[string]*ActivationUsage)
+        err = forEachActivation(options, func(activation whisk.Activation) error {
+            memory, isAction := getActivationMemory(activation)
+            if !isAction {
+                return nil
+            }
+
+            key := getActivationGroup(activation, groupBy)
+            if usage[key] == nil {
+                usage[key] = &ActivationUsage{Group: key}
+            }
+
+            usage[key].Invocations++
+            if !activation.Response.Success {
+                usage[key].Errors++
+            }
+            usage[key].GBSeconds += (float64(activation.Duration) / 1000) * (float64(memory) / 1024)
+
+            return nil
+        })
+        if err != nil {
+            whisk.Debug(whisk.DbgError, "forEachActivation() error: %s\n", err)
+            errStr := wski18n.T("Unable to obtain the list of activations for namespace '{{.name}}': {{.err}}",
+                    map[string]interface{}{"name": getClientNamespace(), "err": err})
+            werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return werr
+        }
+
+        var report []ActivationUsage
+        for _, key := range sortedUsageKeys(usage) {
+            usage[key].Cost = usage[key].GBSeconds * flags.activation.price
+            report = append(report, *usage[key])
+        }
+
+        if format == "json" {
+            printJsonNoColor(report)
+        } else {
+            printUsageCSV(report)
+        }
+
+        return nil
+    },
+}
+
+// ActivationUsage holds the resources consumed by a group of activations
+type ActivationUsage struct {
+    Group       string  `json:"group"`
+    Invocations int     `json:"invocations"`
+    Errors      int     `json:"errors"`
+    GBSeconds   float64 `json:"gbSeconds"`
+    Cost        float64 `json:"cost"`
+}
+
+// Maximum number of activations returned by the server for a single list request
+const ActivationPageSize = 200
+
+// forEachActivation pages through all activations matching options and calls fn for each one
+func forEachActivation(options *whisk.ActivationListOptions, fn func(whisk.Activation) error) error {
+    return forEachActivationPage(options, func(activations []whisk.Activation) error {
+        for _, activation := range activations {
+            if err := fn(activation); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+// forEachActivationPage pages through all activations matching options, starting options.Skip records in, and
+// calls fn for each page.  When no upper bound is given, the listing is pinned to the current time so that new
+// activations do not shift the pages.
+func forEachActivationPage(options *whisk.ActivationListOptions, fn func([]whisk.Activation) error) error {
+    pageOptions := *options
+    pageOptions.Limit = ActivationPageSize
+
+    if pageOptions.Upto == 0 {
+        pageOptions.Upto = time.Now().Unix() * 1000
+    }
+
+    for {
+        activations, _, err := client.Activations.List(&pageOptions)
+        if err != nil {
+            whisk.Debug(whisk.DbgError, "client.Activations.List(%#v) error: %s\n", pageOptions, err)
+            return err
+        }
+
+        if len(activations) > 0 {
+            if err = fn(activations); err != nil {
+                return err
+            }
+        }
+
+        if len(activations) < pageOptions.Limit {
+            return nil
+        }
+        pageOptions.Skip += len(activations)
+    }
+}
+
+// getActivationMemory returns the memory limit, in MB, that the activation ran with.  Activations without
+// a "limits" annotation, such as those of triggers and rules, are not actions and do not consume memory.
+// Sequences are skipped as well since their components are reported on their own.
+func getActivationMemory(activation whisk.Activation) (int, bool) {
+    limits, isMap := getValue(activation.Annotations, "limits").(map[string]interface{})
+    if !isMap || getValueString(activation.Annotations, "kind") == "sequence" {
+        return 0, false
+    }
+
+    if memory, ok := getNumber(limits["memory"]); ok {
+        return int(memory), true
+    }
+
+    return MEMORY_LIMIT, true
+}
+
+// getActivationGroup returns the action, package or namespace name the activation is reported under.  The
+// "path" annotation contains the fully qualified action name, including the package.
+func getActivationGroup(activation whisk.Activation, groupBy string) string {
+    path := getValueString(activation.Annotations, "path")
+    if len(path) == 0 {
+        path = activation.Namespace + "/" + activation.Name
+    }
+    parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+    switch groupBy {
+    case "namespace":
+        return "/" + parts[0]
+    case "package":
+        if len(parts) > 2 {
+            return "/" + parts[0] + "/" + parts[1]
+        }
+        return "/" + parts[0]
+    }
+
+    return "/" + strings.Join(parts, "/")
+}
+
+func sortedUsageKeys(usage map[string]*ActivationUsage) []string {
+    var keys []string
+
+    for key := range usage {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+
+    return keys
+}
+
+func printUsageCSV(report []ActivationUsage) {
+    writer := csv.NewWriter(os.Stdout)
+    writer.Write([]string{"group", "invocations", "errors", "gb_seconds", "cost"})
+
+    for _, usage := range report {
+        writer.Write([]string{
+            usage.Group,
+            strconv.Itoa(usage.Invocations),
+            strconv.Itoa(usage.Errors),
+            strconv.FormatFloat(usage.GBSeconds, 'f', 3, 64),
+            strconv.FormatFloat(usage.Cost, 'f', 6, 64),
+        })
+    }
+
+    writer.Flush()
+}
+
+// Durations may also be given in days and weeks, which time.ParseDuration does not know about
+var longDurationRegex = regexp.MustCompile(`^(?:(\d+)w)?(?:(\d+)d)?(.*)$`)
+
+// parseTimeExpression converts a time given on the command line into milliseconds since Jan 1 1970.  The time
+// may be given as milliseconds, an RFC3339 timestamp, a date, a duration such as "90m" or "2d" before now, or
+// one of the keywords "now", "today" and "yesterday".
+func parseTimeExpression(value string, now time.Time) (int64, error) {
+    var instant time.Time
+
+    midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+    value = strings.TrimSpace(value)
+
+    if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+        return millis, nil
+    } else if t, err := time.Parse(time.RFC3339, value); err == nil {
+        instant = t
+    } else if t, err := time.ParseInLocation("2006-01-02T15:04:05", value, now.Location()); err == nil {
+        instant = t
+    } else if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+        instant = t
+    } else if duration, ok := parseLongDuration(value); ok {
+        instant = now.Add(-duration)
+    } else {
+        switch strings.ToLower(value) {
+        case "now":
+            instant = now
+        case "today":
+            instant = midnight
+        case "yesterday":
+            instant = midnight.AddDate(0, 0, -1)
+        default:
+            whisk.Debug(whisk.DbgError, "Unable to parse time expression '%s'\n", value)
+            errMsg := wski18n.T("'{{.value}}' is not a valid time; use a duration such as 90m or 2d, an RFC3339 timestamp, today, yesterday or milliseconds since Jan 1 1970",
+                map[string]interface{}{"value": value})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.DISPLAY_USAGE)
+            return 0, whiskErr
+        }
+    }
+
+    return instant.UnixNano() / int64(time.Millisecond), nil
+}
+
+func parseLongDuration(value string) (time.Duration, bool) {
+    var duration time.Duration
+
+    match := longDurationRegex.FindStringSubmatch(value)
+    if match == nil || len(value) == 0 {
+        return 0, false
+    }
+
+    weeks, _ := strconv.Atoi(match[1])
+    days, _ := strconv.Atoi(match[2])
+    duration = time.Duration(weeks*7 + days) * 24 * time.Hour
+
+    if len(match[3]) > 0 {
+        rest, err := time.ParseDuration(match[3])
+        if err != nil || rest < 0 {
+            return 0, false
+        }
+        duration += rest
+    }
+
+    return duration, true
+}
+
+// getActivationTimeRange converts the --since and --upto flags shared by the activation commands
+func getActivationTimeRange() (int64, int64, error) {
+    var since, upto int64
+    var err error
+
+    now := time.Now()
+
+    if len(flags.activation.since) > 0 {
+        if since, err = parseTimeExpression(flags.activation.since, now); err != nil {
+            return 0, 0, err
+        }
+    }
+    if len(flags.activation.upto) > 0 {
+        if upto, err = parseTimeExpression(flags.activation.upto, now); err != nil {
+            return 0, 0, err
+        }
+    }
+
+    return since, upto, nil
+}
+
+var activationExportCmd = &cobra.Command{
+    Use:   "export",
+    Short: wski18n.T("export activation records as NDJSON or CSV"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        var output *os.File
+        var checkpoint *ActivationExportCheckpoint
+
+        if whiskErr := checkArgs(args, 0, 0, "Activation export",
+                wski18n.T("No arguments are required.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        format := strings.ToLower(flags.activation.exportFormat)
+        if format != "ndjson" && format != "csv" {
+            whisk.Debug(whisk.DbgError, "Invalid export format '%s'\n", flags.activation.exportFormat)
+            errMsg := wski18n.T("'{{.name}}' is not a valid output format; use ndjson or csv",
+                map[string]interface{}{"name": flags.activation.exportFormat})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        since, upto, err := getActivationTimeRange()
+        if err != nil {
+            return err
+        }
+
+        options := &whisk.ActivationListOptions{
+            Name:  flags.activation.action,
+            Since: since,
+            Upto:  upto,
+            Docs:  true,
+        }
+
+        // Pin the upper bound so that a resumed export sees the same pages
+        if options.Upto == 0 {
+            options.Upto = time.Now().Unix() * 1000
+        }
+
+        if len(flags.activation.out) == 0 {
+            output = os.Stdout
+            checkpoint = &ActivationExportCheckpoint{Name: options.Name, Since: options.Since, Upto: options.Upto,
+                Format: format}
+        } else {
+            if output, checkpoint, err = openActivationExport(flags.activation.out, options, format); err != nil {
+                return err
+            }
+            defer output.Close()
+            options.Since = checkpoint.Since
+            options.Upto = checkpoint.Upto
+            options.Skip = checkpoint.Skip
+        }
+
+        if checkpoint.Offset == 0 && format == "csv" {
+            writeActivationCSV(output, nil)
+        }
+
+        err = forEachActivationPage(options, func(activations []whisk.Activation) error {
+            var err error
+
+            if format == "csv" {
+                err = writeActivationCSV(output, activations)
+            } else {
+                err = writeActivationNDJSON(output, activations)
+            }
+            if err != nil {
+                return err
+            }
+
+            checkpoint.Skip += len(activations)
+            checkpoint.Count += len(activations)
+            if output != os.Stdout {
+                return checkpoint.save(flags.activation.out, output)
+            }
+
+            return nil
+        })
+        if err != nil {
+            whisk.Debug(whisk.DbgError, "forEachActivationPage() error: %s\n", err)
+            errStr := wski18n.T("Unable to export activations for namespace '{{.name}}': {{.err}}",
+                    map[string]interface{}{"name": getClientNamespace(), "err": err})
+            werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return werr
+        }
+
+        if output != os.Stdout {
+            os.Remove(getCheckpointFileName(flags.activation.out))
+            fmt.Fprintf(color.Output, wski18n.T("{{.ok}} exported {{.count}} activations to {{.name}}\n",
+                map[string]interface{}{"ok": color.GreenString("ok:"), "count": checkpoint.Count,
+                "name": boldString(flags.activation.out)}))
+        }
+
+        return nil
+    },
+}
+
+// ActivationExportCheckpoint records the progress of an activation export so that an interrupted export can be
+// resumed.  Offset is the size of the export file once the last complete page was written.  The time flags are
+// kept as given to match a resumed export against the checkpoint, and the times they resolved to are reused, since a
+// relative time like "2d" resolves differently when the export is resumed.
+type ActivationExportCheckpoint struct {
+    Name        string  `json:"name,omitempty"`
+    SinceFlag   string  `json:"sinceFlag,omitempty"`
+    UptoFlag    string  `json:"uptoFlag,omitempty"`
+    Since       int64   `json:"since"`
+    Upto        int64   `json:"upto"`
+    Format      string  `json:"format"`
+    Skip        int     `json:"skip"`
+    Count       int     `json:"count"`
+    Offset      int64   `json:"offset"`
+}
+
+func getCheckpointFileName(filename string) string {
+    return filename + ".checkpoint"
+}
+
+func (checkpoint *ActivationExportCheckpoint) save(filename string, output *os.File) error {
+    var err error
+
+    if err = output.Sync(); err != nil {
+        return err
+    }
+    if checkpoint.Offset, err = output.Seek(0, os.SEEK_CUR); err != nil {
+        return err
+    }
+
+    data, err := json.Marshal(checkpoint)
+    if err != nil {
+        return err
+    }
+
+    return ioutil.WriteFile(getCheckpointFileName(filename), data, 0644)
+}
+
+// openActivationExport opens the export file.  If a checkpoint from an earlier export with the same filters
+// exists, the file is truncated to the last complete page and the export resumes from there.
+func openActivationExport(filename string, options *whisk.ActivationListOptions,
+    format string) (*os.File, *ActivationExportCheckpoint, error) {
+    checkpoint := &ActivationExportCheckpoint{Name: options.Name, SinceFlag: flags.activation.since,
+        UptoFlag: flags.activation.upto, Since: options.Since, Upto: options.Upto, Format: format}
+    flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+
+    if data, err := ioutil.ReadFile(getCheckpointFileName(filename)); err == nil {
+        var saved ActivationExportCheckpoint
+
+        if err = json.Unmarshal(data, &saved); err != nil {
+            whisk.Debug(whisk.DbgError, "json.Unmarshal(%s) error: %s\n", data, err)
+        } else if saved.Name != checkpoint.Name || saved.SinceFlag != checkpoint.SinceFlag ||
+                saved.UptoFlag != checkpoint.UptoFlag || saved.Format != format {
+            whisk.Debug(whisk.DbgError, "Checkpoint %#v does not match export %#v\n", saved, checkpoint)
+            errMsg := wski18n.T("The checkpoint '{{.name}}' belongs to an export with different options. Delete it or export to another file.",
+                map[string]interface{}{"name": getCheckpointFileName(filename)})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.NO_DISPLAY_USAGE)
+            return nil, nil, whiskErr
+        } else {
+            whisk.Debug(whisk.DbgInfo, "Resuming export from checkpoint %#v\n", saved)
+            checkpoint = &saved
+            flag = os.O_CREATE | os.O_WRONLY
+        }
+    }
+
+    output, err := os.OpenFile(filename, flag, 0644)
+    if err == nil && checkpoint.Offset > 0 {
+        if err = output.Truncate(checkpoint.Offset); err == nil {
+            _, err = output.Seek(checkpoint.Offset, os.SEEK_SET)
+        }
+    }
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "os.OpenFile(%s) error: %s\n", filename, err)
+        errMsg := wski18n.T("Unable to write '{{.name}}': {{.err}}",
+            map[string]interface{}{"name": filename, "err": err})
+        whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return nil, nil, whiskErr
+    }
+
+    return output, checkpoint, nil
+}
+
+func writeActivationNDJSON(output io.Writer, activations []whisk.Activation) error {
+    encoder := json.NewEncoder(output)
+
+    for _, activation := range activations {
+        if err := encoder.Encode(activation); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// writeActivationCSV writes one row per activation; the result and annotations are JSON encoded and the log
+// lines are separated by newlines.  The header row is written when no activations are given.
+func writeActivationCSV(output io.Writer, activations []whisk.Activation) error {
+    writer := csv.NewWriter(output)
+
+    if activations == nil {
+        writer.Write([]string{"namespace", "name", "version", "subject", "activationId", "cause", "start", "end",
+            "duration", "status", "statusCode", "success", "result", "logs", "annotations"})
+    }
+
+    for _, activation := range activations {
+        result, _ := json.Marshal(activation.Response.Result)
+        annotations, _ := json.Marshal(activation.Annotations)
+
+        writer.Write([]string{
+            activation.Namespace,
+            activation.Name,
+            activation.Version,
+            activation.Subject,
+            activation.ActivationID,
+            activation.Cause,
+            strconv.FormatInt(activation.Start, 10),
+            strconv.FormatInt(activation.End, 10),
+            strconv.FormatInt(activation.Duration, 10),
+            activation.Response.Status,
+            strconv.Itoa(activation.Response.StatusCode),
+            strconv.FormatBool(activation.Response.Success),
+            string(result),
+            strings.Join(activation.Logs, "\n"),
+            string(annotations),
+        })
+    }
+
+    writer.Flush()
+    return writer.Error()
+}
+
+// ActivationFilter selects activation records on the client side, since the server can only filter by name and
+// time
+type ActivationFilter struct {
+    Name            string
+    Since           int64
+    Upto            int64
+    Status          string
+    MinDuration     int64
+    MaxDuration     int64
+    Cold            bool
+    ResultContains  string
+    LogsContains    string
+}
+
+// Response status values accepted by the --status flag, mapped to those reported by the server
+var activationStatuses = map[string]string{
+    "success":              "success",
+    "application error":    "application error",
+    "developer error":      "action developer error",
+    "whisk internal error": "whisk internal error",
+}
+
+// getActivationFilter builds an ActivationFilter from the command line flags
+func getActivationFilter() (*ActivationFilter, error) {
+    since, upto, err := getActivationTimeRange()
+    if err != nil {
+        return nil, err
+    }
+
+    filter := &ActivationFilter{
+        Name:           flags.activation.action,
+        Since:          since,
+        Upto:           upto,
+        MinDuration:    flags.activation.minDuration,
+        MaxDuration:    flags.activation.maxDuration,
+        Cold:           flags.activation.cold,
+        ResultContains: flags.activation.resultContains,
+        LogsContains:   flags.activation.logsContains,
+    }
+
+    if len(flags.activation.status) > 0 {
+        status, isValid := activationStatuses[strings.ToLower(flags.activation.status)]
+        if !isValid {
+            whisk.Debug(whisk.DbgError, "Invalid activation status '%s'\n", flags.activation.status)
+            errMsg := wski18n.T("'{{.name}}' is not a valid status; use success, application error, developer error or whisk internal error",
+                map[string]interface{}{"name": flags.activation.status})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.DISPLAY_USAGE)
+            return nil, whiskErr
+        }
+        filter.Status = status
+    }
+
+    return filter, nil
+}
+
+func (filter *ActivationFilter) matches(activation whisk.Activation) bool {
+    if len(filter.Name) > 0 && filter.Name != activation.Name {
+        path := getValueString(activation.Annotations, "path")
+        if filter.Name != path && filter.Name != "/" + path {
+            return false
+        }
+    }
+
+    if (filter.Since > 0 && activation.Start < filter.Since) || (filter.Upto > 0 && activation.Start > filter.Upto) {
+        return false
+    }
+
+    if len(filter.Status) > 0 && filter.Status != activation.Response.Status {
+        return false
+    }
+
+    if (filter.MinDuration > 0 && activation.Duration < filter.MinDuration) ||
+            (filter.MaxDuration > 0 && activation.Duration > filter.MaxDuration) {
+        return false
+    }
+
+    // Only activations that had to initialize a container carry an initTime annotation
+    if filter.Cold && getValue(activation.Annotations, "initTime") == nil {
+        return false
+    }
+
+    if len(filter.ResultContains) > 0 {
+        result, _ := json.Marshal(activation.Response.Result)
+        if !strings.Contains(string(result), filter.ResultContains) {
+            return false
+        }
+    }
+
+    if len(filter.LogsContains) > 0 && !strings.Contains(strings.Join(activation.Logs, "\n"), filter.LogsContains) {
+        return false
+    }
+
+    return true
+}
+
+// isClientSide reports whether the filter uses criteria that the server cannot apply
+func (filter *ActivationFilter) isClientSide() bool {
+    return len(filter.Status) > 0 || filter.MinDuration > 0 || filter.MaxDuration > 0 || filter.Cold ||
+        len(filter.ResultContains) > 0 || len(filter.LogsContains) > 0
+}
+
+var errActivationLimitReached = errors.New("activation limit reached")
+
+// listFilteredActivations pages through the activations selected by options until options.Limit activations
+// matching the filter are found.  options.Skip applies to the matching activations.
+func listFilteredActivations(options *whisk.ActivationListOptions,
+    filter *ActivationFilter) ([]whisk.Activation, error) {
+    var activations []whisk.Activation
+
+    skip := options.Skip
+    pageOptions := *options
+    pageOptions.Skip = 0
+    pageOptions.Docs = true
+
+    err := forEachActivation(&pageOptions, func(activation whisk.Activation) error {
+        if !filter.matches(activation) {
+            return nil
+        }
+
+        if skip > 0 {
+            skip--
+            return nil
+        }
+
+        activations = append(activations, activation)
+        if options.Limit > 0 && len(activations) >= options.Limit {
+            return errActivationLimitReached
+        }
+
+        return nil
+    })
+    if err == errActivationLimitReached {
+        err = nil
+    }
+
+    return activations, err
+}
+
 func init() {
     activationListCmd.Flags().IntVarP(&flags.common.skip, "skip", "s", 0, wski18n.T("exclude the first `SKIP` number of activations from the result"))
     activationListCmd.Flags().IntVarP(&flags.common.limit, "limit", "l", 30, wski18n.T("only return `LIMIT` number of activations from the collection"))
     activationListCmd.Flags().BoolVarP(&flags.common.full, "full", "f", false, wski18n.T("include full activation description"))
-    activationListCmd.Flags().Int64Var(&flags.activation.upto, "upto", 0, wski18n.T("return activations with timestamps earlier than `UPTO`; measured in milliseconds since Th, 01, Jan 1970"))
-    activationListCmd.Flags().Int64Var(&flags.activation.since, "since", 0, wski18n.T("return activations with timestamps later than `SINCE`; measured in milliseconds since Th, 01, Jan 1970"))
+    activationListCmd.Flags().StringVar(&flags.activation.upto, "upto", "", wski18n.T("return activations with timestamps earlier than `UPTO`; a duration such as 90m or 2d, an RFC3339 timestamp, today, or milliseconds since Th, 01, Jan 1970"))
+    activationListCmd.Flags().StringVar(&flags.activation.since, "since", "", wski18n.T("return activations with timestamps later than `SINCE`; a duration such as 90m or 2d, an RFC3339 timestamp, today, or milliseconds since Th, 01, Jan 1970"))
+
+    activationListCmd.Flags().StringVar(&flags.activation.status, "status", "", wski18n.T("only include activations with `STATUS`: success, application error, developer error or whisk internal error"))
+    activationListCmd.Flags().Int64Var(&flags.activation.minDuration, "min-duration", 0, wski18n.T("only include activations that ran for at least `MILLISECONDS`"))
+    activationListCmd.Flags().Int64Var(&flags.activation.maxDuration, "max-duration", 0, wski18n.T("only include activations that ran for at most `MILLISECONDS`"))
+    activationListCmd.Flags().BoolVar(&flags.activation.cold, "cold", false, wski18n.T("only include activations that started a new container"))
+    activationListCmd.Flags().StringVar(&flags.activation.resultContains, "result-contains", "", wski18n.T("only include activations whose result contains `TEXT`"))
+    activationListCmd.Flags().StringVar(&flags.activation.logsContains, "logs-contains", "", wski18n.T("only include activations whose logs contain `TEXT`"))
 
     activationGetCmd.Flags().BoolVarP(&flags.common.summary, "summary", "s", false, wski18n.T("summarize activation details"))
 
     activationPollCmd.Flags().IntVarP(&flags.activation.exit, "exit", "e", 0, wski18n.T("stop polling after `SECONDS` seconds"))
+    activationPollCmd.Flags().StringVar(&flags.activation.since, "since", "", wski18n.T("start polling for activations from `SINCE`; a duration such as 90m or 2d, an RFC3339 timestamp, today, or milliseconds since Th, 01, Jan 1970"))
     activationPollCmd.Flags().IntVar(&flags.activation.sinceSeconds, "since-seconds", 0, wski18n.T("start polling for activations `SECONDS` seconds ago"))
     activationPollCmd.Flags().IntVar(&flags.activation.sinceMinutes, "since-minutes", 0, wski18n.T("start polling for activations `MINUTES` minutes ago"))
     activationPollCmd.Flags().IntVar(&flags.activation.sinceHours, "since-hours", 0, wski18n.T("start polling for activations `HOURS` hours ago"))
     activationPollCmd.Flags().IntVar(&flags.activation.sinceDays, "since-days", 0, wski18n.T("start polling for activations `DAYS` days ago"))
 
+    activationUsageCmd.Flags().StringVar(&flags.activation.upto, "upto", "", wski18n.T("return activations with timestamps earlier than `UPTO`; a duration such as 90m or 2d, an RFC3339 timestamp, today, or milliseconds since Th, 01, Jan 1970"))
+    activationUsageCmd.Flags().StringVar(&flags.activation.since, "since", "", wski18n.T("return activations with timestamps later than `SINCE`; a duration such as 90m or 2d, an RFC3339 timestamp, today, or milliseconds since Th, 01, Jan 1970"))
+    activationUsageCmd.Flags().StringVar(&flags.activation.groupBy, "group-by", "action", wski18n.T("group usage by `GROUP`: action, package or namespace"))
+    activationUsageCmd.Flags().StringVar(&flags.activation.format, "format", "csv", wski18n.T("output `FORMAT`: csv or json"))
+    activationUsageCmd.Flags().Float64Var(&flags.activation.price, "price", 0, wski18n.T("`PRICE` charged per GB-second"))
+
+    activationExportCmd.Flags().StringVar(&flags.activation.action, "name", "", wski18n.T("only export activations of the entity named `NAME`"))
+    activationExportCmd.Flags().StringVar(&flags.activation.upto, "upto", "", wski18n.T("return activations with timestamps earlier than `UPTO`; a duration such as 90m or 2d, an RFC3339 timestamp, today, or milliseconds since Th, 01, Jan 1970"))
+    activationExportCmd.Flags().StringVar(&flags.activation.since, "since", "", wski18n.T("return activations with timestamps later than `SINCE`; a duration such as 90m or 2d, an RFC3339 timestamp, today, or milliseconds since Th, 01, Jan 1970"))
+    activationExportCmd.Flags().StringVar(&flags.activation.exportFormat, "format", "ndjson", wski18n.T("output `FORMAT`: ndjson or csv"))
+    activationExportCmd.Flags().StringVarP(&flags.activation.out, "out", "o", "", wski18n.T("write the activations to `FILE`; an interrupted export to the same file is resumed"))
+
     activationCmd.AddCommand(
         activationListCmd,
         activationGetCmd,
         activationLogsCmd,
         activationResultCmd,
         activationPollCmd,
+        activationUsageCmd,
+        activationExportCmd,
     )
 }
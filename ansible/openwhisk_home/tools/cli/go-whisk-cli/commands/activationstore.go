@@ -0,0 +1,336 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+    "bufio"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "../../go-whisk/whisk"
+    "../wski18n"
+
+    "github.com/fatih/color"
+    "github.com/mitchellh/go-homedir"
+    "github.com/spf13/cobra"
+)
+
+const DefaultActivationStore string = "~/.wskactivations"
+
+const (
+    activationStoreRecords = "activations.ndjson"
+    activationStoreCursor  = "cursor.json"
+)
+
+// ActivationStore is a local, append only archive of the activation records of one namespace on one API host
+type ActivationStore struct {
+    Dir         string
+    Namespace   string
+    Cursor      ActivationStoreCursor
+}
+
+// ActivationStoreCursor marks how far the store has been synchronized.  Since is the start time of the newest
+// record in the store; Seen lists the IDs of the records that started at that time, as the next sync will list
+// them again.  Namespace is the namespace the records were listed from.
+type ActivationStoreCursor struct {
+    Namespace   string      `json:"namespace"`
+    Since       int64       `json:"since"`
+    Seen        []string    `json:"seen"`
+}
+
+var activationSyncCmd = &cobra.Command{
+    Use:   "sync",
+    Short: wski18n.T("copy new activation records into the local activation store"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if whiskErr := checkArgs(args, 0, 0, "Activation sync",
+                wski18n.T("No arguments are required.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        store, err := openActivationStore()
+        if err != nil {
+            return err
+        }
+
+        count, err := store.sync()
+        if err != nil {
+            whisk.Debug(whisk.DbgError, "store.sync() error: %s\n", err)
+            errStr := wski18n.T("Unable to synchronize the activation store '{{.name}}': {{.err}}",
+                    map[string]interface{}{"name": store.Dir, "err": err})
+            werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return werr
+        }
+
+        fmt.Fprintf(color.Output, wski18n.T("{{.ok}} synchronized {{.count}} new activations into {{.name}}\n",
+            map[string]interface{}{"ok": color.GreenString("ok:"), "count": count, "name": boldString(store.Dir)}))
+
+        return nil
+    },
+}
+
+var activationQueryCmd = &cobra.Command{
+    Use:   "query",
+    Short: wski18n.T("search the local activation store"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        var activations []whisk.Activation
+
+        if whiskErr := checkArgs(args, 0, 0, "Activation query",
+                wski18n.T("No arguments are required.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        filter, err := getActivationFilter()
+        if err != nil {
+            return err
+        }
+
+        store, err := openActivationStore()
+        if err != nil {
+            return err
+        }
+
+        err = store.forEach(func(activation whisk.Activation) error {
+            if filter.matches(activation) {
+                activations = append(activations, activation)
+            }
+            return nil
+        })
+        if err != nil {
+            whisk.Debug(whisk.DbgError, "store.forEach() error: %s\n", err)
+            errStr := wski18n.T("Unable to read the activation store '{{.name}}': {{.err}}",
+                    map[string]interface{}{"name": store.Dir, "err": err})
+            werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return werr
+        }
+
+        // Newest first, as the server lists them
+        sort.Sort(activationsByStart(activations))
+        if flags.common.limit > 0 && len(activations) > flags.common.limit {
+            activations = activations[:flags.common.limit]
+        }
+
+        if flags.common.full {
+            printFullActivationList(activations)
+        } else {
+            printList(activations)
+        }
+
+        return nil
+    },
+}
+
+type activationsByStart []whisk.Activation
+
+func (a activationsByStart) Len() int           { return len(a) }
+func (a activationsByStart) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a activationsByStart) Less(i, j int) bool { return a[i].Start > a[j].Start }
+
+// openActivationStore opens the store for the current API host, subject, and namespace.  The subject is taken from
+// the authorization key so that the store can be located without contacting the server.
+func openActivationStore() (*ActivationStore, error) {
+    replacer := strings.NewReplacer("://", "_", "/", "_", ":", "_")
+    subject := strings.Split(Properties.Auth, ":")[0]
+    host := replacer.Replace(Properties.APIHost)
+    namespace := getClientNamespace()
+
+    if len(subject) == 0 || len(host) == 0 {
+        errMsg := wski18n.T("An API host and authorization key are required to locate the activation store.")
+        whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+            whisk.NO_DISPLAY_USAGE)
+        return nil, whiskErr
+    }
+
+    dir, err := homedir.Expand(DefaultActivationStore)
+    if err == nil {
+        dir = filepath.Join(dir, host, subject, replacer.Replace(namespace))
+        err = os.MkdirAll(dir, 0700)
+    }
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "Unable to create activation store directory '%s': %s\n", dir, err)
+        errMsg := wski18n.T("Unable to open the activation store '{{.name}}': {{.err}}",
+            map[string]interface{}{"name": dir, "err": err})
+        whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return nil, whiskErr
+    }
+
+    store := &ActivationStore{Dir: dir, Namespace: namespace}
+
+    if data, err := ioutil.ReadFile(filepath.Join(dir, activationStoreCursor)); err == nil {
+        if err = json.Unmarshal(data, &store.Cursor); err != nil {
+            whisk.Debug(whisk.DbgError, "json.Unmarshal(%s) error: %s\n", data, err)
+            errMsg := wski18n.T("Unable to open the activation store '{{.name}}': {{.err}}",
+                map[string]interface{}{"name": dir, "err": err})
+            whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return nil, whiskErr
+        }
+    }
+
+    // Records of another namespace would mix into queries, and its cursor would skip older records of this one
+    if len(store.Cursor.Namespace) > 0 && store.Cursor.Namespace != namespace {
+        whisk.Debug(whisk.DbgError, "Activation store '%s' holds namespace '%s', not '%s'\n", dir,
+            store.Cursor.Namespace, namespace)
+        errMsg := wski18n.T("The activation store '{{.name}}' holds the activations of namespace '{{.stored}}', not '{{.namespace}}'.",
+            map[string]interface{}{"name": dir, "stored": store.Cursor.Namespace, "namespace": namespace})
+        whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+            whisk.NO_DISPLAY_USAGE)
+        return nil, whiskErr
+    }
+
+    return store, nil
+}
+
+// sync appends the activations started since the cursor to the store and advances the cursor.  New records are
+// staged in a temporary file and only added once the listing completes, so an interrupted sync leaves the store
+// unchanged.
+func (store *ActivationStore) sync() (int, error) {
+    var count int
+
+    seen := make(map[string]bool)
+    for _, id := range store.Cursor.Seen {
+        seen[id] = true
+    }
+    cursor := store.Cursor
+    cursor.Namespace = store.Namespace
+
+    staged, err := ioutil.TempFile(store.Dir, "sync")
+    if err != nil {
+        return 0, err
+    }
+    defer os.Remove(staged.Name())
+    defer staged.Close()
+
+    options := &whisk.ActivationListOptions{
+        Since: store.Cursor.Since,
+        Docs:  true,
+    }
+    encoder := json.NewEncoder(staged)
+
+    err = forEachActivation(options, func(activation whisk.Activation) error {
+        if seen[activation.ActivationID] {
+            return nil
+        }
+
+        if activation.Start > cursor.Since {
+            cursor.Since = activation.Start
+            cursor.Seen = nil
+        }
+        if activation.Start == cursor.Since {
+            cursor.Seen = append(cursor.Seen, activation.ActivationID)
+        }
+
+        count++
+        return encoder.Encode(activation)
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    if count == 0 {
+        return 0, nil
+    }
+
+    if _, err = staged.Seek(0, os.SEEK_SET); err != nil {
+        return 0, err
+    }
+
+    records, err := os.OpenFile(filepath.Join(store.Dir, activationStoreRecords),
+        os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+    if err != nil {
+        return 0, err
+    }
+    defer records.Close()
+
+    if _, err = io.Copy(records, staged); err != nil {
+        return 0, err
+    }
+    if err = records.Sync(); err != nil {
+        return 0, err
+    }
+
+    data, err := json.Marshal(cursor)
+    if err != nil {
+        return 0, err
+    }
+    if err = ioutil.WriteFile(filepath.Join(store.Dir, activationStoreCursor), data, 0600); err != nil {
+        return 0, err
+    }
+    store.Cursor = cursor
+
+    return count, nil
+}
+
+// forEach reads the store one record at a time and calls fn for each activation
+func (store *ActivationStore) forEach(fn func(whisk.Activation) error) error {
+    records, err := os.Open(filepath.Join(store.Dir, activationStoreRecords))
+    if os.IsNotExist(err) {
+        return nil
+    } else if err != nil {
+        return err
+    }
+    defer records.Close()
+
+    decoder := json.NewDecoder(bufio.NewReader(records))
+    for {
+        var activation whisk.Activation
+
+        if err = decoder.Decode(&activation); err == io.EOF {
+            return nil
+        } else if err != nil {
+            return err
+        }
+
+        if err = fn(activation); err != nil {
+            return err
+        }
+    }
+}
+
+func init() {
+    activationQueryCmd.Flags().StringVar(&flags.activation.action, "name", "", wski18n.T("only include activations of the entity named `NAME`"))
+    activationQueryCmd.Flags().StringVar(&flags.activation.status, "status", "", wski18n.T("only include activations with `STATUS`: success, application error, developer error or whisk internal error"))
+    activationQueryCmd.Flags().StringVar(&flags.activation.upto, "upto", "", wski18n.T("return activations with timestamps earlier than `UPTO`; a duration such as 90m or 2d, an RFC3339 timestamp, today, or milliseconds since Th, 01, Jan 1970"))
+    activationQueryCmd.Flags().StringVar(&flags.activation.since, "since", "", wski18n.T("return activations with timestamps later than `SINCE`; a duration such as 90m or 2d, an RFC3339 timestamp, today, or milliseconds since Th, 01, Jan 1970"))
+    activationQueryCmd.Flags().Int64Var(&flags.activation.minDuration, "min-duration", 0, wski18n.T("only include activations that ran for at least `MILLISECONDS`"))
+    activationQueryCmd.Flags().Int64Var(&flags.activation.maxDuration, "max-duration", 0, wski18n.T("only include activations that ran for at most `MILLISECONDS`"))
+    activationQueryCmd.Flags().BoolVar(&flags.activation.cold, "cold", false, wski18n.T("only include activations that started a new container"))
+    activationQueryCmd.Flags().StringVar(&flags.activation.resultContains, "result-contains", "", wski18n.T("only include activations whose result contains `TEXT`"))
+    activationQueryCmd.Flags().StringVar(&flags.activation.logsContains, "logs-contains", "", wski18n.T("only include activations whose logs contain `TEXT`"))
+    activationQueryCmd.Flags().IntVarP(&flags.common.limit, "limit", "l", 30, wski18n.T("only return `LIMIT` number of activations from the collection"))
+    activationQueryCmd.Flags().BoolVarP(&flags.common.full, "full", "f", false, wski18n.T("include full activation description"))
+
+    activationCmd.AddCommand(
+        activationSyncCmd,
+        activationQueryCmd,
+    )
+}
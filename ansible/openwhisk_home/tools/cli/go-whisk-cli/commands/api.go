@@ -19,7 +19,12 @@ package commands
 import (
     "errors"
     "fmt"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "path/filepath"
     "reflect"
+    "sort"
     "strconv"
     "strings"
 
@@ -29,6 +34,7 @@ import (
     "github.com/fatih/color"
     "github.com/spf13/cobra"
     "encoding/json"
+    "gopkg.in/yaml.v2"
 )
 
 //////////////
@@ -49,8 +55,15 @@ var apiCreateCmd = &cobra.Command{
     RunE: func(cmd *cobra.Command, args []string) error {
 
         var api *whisk.Api
+        var rateLimit *whisk.ApiRateLimit
         var err error
 
+        if (len(flags.api.rateLimit) > 0) {
+            if rateLimit, err = parseApiRateLimit(flags.api.rateLimit); err != nil {
+                return err
+            }
+        }
+
         if (len(args) == 0 && flags.api.configfile == "") {
             whisk.Debug(whisk.DbgError, "No swagger file and no arguments\n")
             errMsg := wski18n.T("Invalid argument(s). Specify a swagger file or specify an API base path with an API path, an API verb, and an action name.")
@@ -66,6 +79,22 @@ var apiCreateCmd = &cobra.Command{
                     whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
                 return whiskErr
             }
+            if (hasApiPolicyFlags()) {
+                // Apply the policies to every operation in the configuration file
+                swaggerObj := new(whisk.ApiSwagger)
+                if err = json.Unmarshal([]byte(api.Swagger), swaggerObj); err != nil {
+                    whisk.Debug(whisk.DbgError, "json.Unmarshal(%s) error: %s\n", api.Swagger, err)
+                    errMsg := wski18n.T("Unable to apply the API policies to the configuration file '{{.name}}': {{.err}}",
+                        map[string]interface{}{"name": flags.api.configfile, "err": err})
+                    whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                        whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+                    return whiskErr
+                }
+                applyApiPolicies(swaggerObj, "", "", rateLimit)
+                if api.Swagger, err = marshalApiSwagger(swaggerObj); err != nil {
+                    return err
+                }
+            }
         } else {
             if whiskErr := checkArgs(args, 3, 4, "Api create",
                 wski18n.T("Specify a swagger file or specify an API base path with an API path, an API verb, and an action name.")); whiskErr != nil {
@@ -94,6 +123,14 @@ var apiCreateCmd = &cobra.Command{
             return whiskErr
         }
 
+        // The gateway generates the swagger for APIs created from arguments, so the policies are
+        // applied to the created operation and the API is updated with them
+        if (api.Swagger == "" && hasApiPolicyFlags()) {
+            if retApi, err = updateApiPolicies(retApi, api, rateLimit); err != nil {
+                return removeCreatedApi(api, err)
+            }
+        }
+
         if (api.Swagger == "") {
             baseUrl := retApi.BaseUrl
             fmt.Fprintf(color.Output,
@@ -132,47 +169,129 @@ var apiCreateCmd = &cobra.Command{
 }
 
 var apiUpdateCmd = &cobra.Command{
-    Use:           "update API_PATH API_VERB ACTION",
+    Use:           "update BASE_PATH API_PATH API_VERB [--action ACTION] [--path API_PATH] [--method API_VERB]",
     Short:         wski18n.T("update an existing API"),
     SilenceUsage:  true,
     SilenceErrors: true,
     PreRunE:       setupClientConfig,
     RunE: func(cmd *cobra.Command, args []string) error {
+        var qName QualifiedName
+        var err error
 
         if whiskErr := checkArgs(args, 3, 3, "Api update",
-            wski18n.T("An API path, an API verb, and an action name are required.")); whiskErr != nil {
+            wski18n.T("An API base path, an API path, and an API verb are required.")); whiskErr != nil {
             return whiskErr
         }
 
-        api, err := parseApi(cmd, args)
-        if err != nil {
-            whisk.Debug(whisk.DbgError, "parseApi(%s, %s) error: %s\n", cmd, args, err)
-            errMsg := wski18n.T("Unable to parse API command arguments: {{.err}}", map[string]interface{}{"err": err})
-            whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+        // Validate the existing API operation and the requested changes the same way parseApi does
+        if whiskErr, ok := isValidRelpath(args[1]); !ok {
+            return whiskErr
+        }
+        if whiskErr, ok := IsValidApiVerb(args[2]); !ok {
+            return whiskErr
+        }
+
+        if len(flags.api.action) == 0 && len(flags.api.path) == 0 && len(flags.api.verb) == 0 {
+            whisk.Debug(whisk.DbgError, "No API changes specified\n")
+            errMsg := wski18n.T("Specify a new action, API path, or API verb with --action, --path, or --method.")
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL,
                 whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
             return whiskErr
         }
+        if len(flags.api.path) > 0 {
+            if whiskErr, ok := isValidRelpath(flags.api.path); !ok {
+                return whiskErr
+            }
+        }
+        if len(flags.api.verb) > 0 {
+            if whiskErr, ok := IsValidApiVerb(flags.api.verb); !ok {
+                return whiskErr
+            }
+        }
+        if len(flags.api.action) > 0 {
+            qName, err = parseQualifiedName(flags.api.action)
+            if err != nil {
+                whisk.Debug(whisk.DbgError, "parseQualifiedName(%s) failed: %s\n", flags.api.action, err)
+                errMsg := wski18n.T("'{{.name}}' is not a valid action name: {{.err}}",
+                    map[string]interface{}{"name": flags.api.action, "err": err})
+                whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                    whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
+                return whiskErr
+            }
+            if (qName.entityName == "") {
+                whisk.Debug(whisk.DbgError, "Action name '%s' is invalid\n", flags.api.action)
+                errMsg := wski18n.T("'{{.name}}' is not a valid action name.", map[string]interface{}{"name": flags.api.action})
+                whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL,
+                    whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
+                return whiskErr
+            }
+        }
+
+        retApi, err := getApiConfiguration(args[0])
+        if err != nil {
+            return err
+        }
+
+        relpath, verb := args[1], strings.ToLower(args[2])
+        newRelpath, newVerb := relpath, verb
+        if len(flags.api.path) > 0 {
+            newRelpath = flags.api.path
+        }
+        if len(flags.api.verb) > 0 {
+            newVerb = strings.ToLower(flags.api.verb)
+        }
+
+        operation, err := moveApiOperation(retApi.Swagger, relpath, verb, newRelpath, newVerb)
+        if err != nil {
+            return err
+        }
+
+        if len(flags.api.action) > 0 {
+            setApiOperationAction(operation, qName)
+        }
+
+        api := new(whisk.Api)
+        api.Namespace = client.Config.Namespace
+        if api.Swagger, err = marshalApiSwagger(retApi.Swagger); err != nil {
+            return err
+        }
+
         sendApi := new(whisk.SendApi)
         sendApi.ApiDoc = api
 
-        retApi, _, err := client.Apis.Insert(sendApi, true)
+        retApi, _, err = client.Apis.Insert(sendApi, true)
         if err != nil {
-            whisk.Debug(whisk.DbgError, "client.Apis.Insert(%#v, %t, false) error: %s\n", api, err)
+            whisk.Debug(whisk.DbgError, "client.Apis.Insert(%#v, true) error: %s\n", api, err)
             errMsg := wski18n.T("Unable to update API: {{.err}}", map[string]interface{}{"err": err})
             whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_NETWORK,
                 whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
             return whiskErr
         }
 
-        fmt.Fprintf(color.Output,
-            wski18n.T("{{.ok}} updated API {{.path}} {{.verb}} for action {{.name}}\n{{.fullpath}}\n",
-                map[string]interface{}{
-                    "ok": color.GreenString("ok:"),
-                    "path": api.GatewayRelPath,
-                    "verb": api.GatewayMethod,
-                    "name": boldString("/"+api.Action.Name),
-                    "fullpath": getManagedUrl(retApi, api.GatewayRelPath, api.GatewayMethod),
-                }))
+        // Operations defined by a config file need not name an action
+        actionName, _ := operation["x-ibm-op-ext"]["actionName"].(string)
+        actionNamespace, _ := operation["x-ibm-op-ext"]["actionNamespace"].(string)
+
+        if len(actionName) > 0 {
+            fmt.Fprintf(color.Output,
+                wski18n.T("{{.ok}} updated API {{.path}} {{.verb}} for action {{.name}}\n{{.fullpath}}\n",
+                    map[string]interface{}{
+                        "ok": color.GreenString("ok:"),
+                        "path": newRelpath,
+                        "verb": strings.ToUpper(newVerb),
+                        "name": boldString("/"+actionNamespace+"/"+actionName),
+                        "fullpath": getManagedUrl(retApi, newRelpath, newVerb),
+                    }))
+        } else {
+            fmt.Fprintf(color.Output,
+                wski18n.T("{{.ok}} updated API {{.path}} {{.verb}}\n{{.fullpath}}\n",
+                    map[string]interface{}{
+                        "ok": color.GreenString("ok:"),
+                        "path": newRelpath,
+                        "verb": strings.ToUpper(newVerb),
+                        "fullpath": getManagedUrl(retApi, newRelpath, newVerb),
+                    }))
+        }
         return nil
     },
 }
@@ -244,6 +363,177 @@ var apiGetCmd = &cobra.Command{
     },
 }
 
+var apiExportCmd = &cobra.Command{
+    Use:           "export BASE_PATH | API_NAME",
+    Short:         wski18n.T("export an API as a Swagger 2.0 document"),
+    SilenceUsage:  true,
+    SilenceErrors: true,
+    PreRunE:       setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        var output []byte
+
+        if whiskErr := checkArgs(args, 1, 1, "Api export",
+            wski18n.T("An API base path or API name is required.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        if (flags.api.format != "json" && flags.api.format != "yaml") {
+            whisk.Debug(whisk.DbgError, "Invalid export format: %s\n", flags.api.format)
+            errMsg := wski18n.T("'{{.format}}' is not a valid export format. Valid values are: json, yaml",
+                map[string]interface{}{"format": flags.api.format})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        retApi, err := getApiConfiguration(args[0])
+        if err != nil {
+            return err
+        }
+
+        swagger := getExportSwagger(retApi.Swagger)
+        output, err = json.MarshalIndent(swagger, "", "    ")
+        if err == nil && flags.api.format == "yaml" {
+            output, err = jsonToYaml(output)
+        }
+        if err != nil {
+            whisk.Debug(whisk.DbgError, "Marshal of swagger %#v to %s failed: %s\n", swagger, flags.api.format, err)
+            errMsg := wski18n.T("Unable to export API: {{.err}}", map[string]interface{}{"err": err})
+            whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return whiskErr
+        }
+        if (len(output) > 0 && output[len(output)-1] != '\n') {
+            output = append(output, '\n')
+        }
+
+        if (len(flags.api.out) == 0) {
+            fmt.Print(string(output))
+            return nil
+        }
+
+        if err = ioutil.WriteFile(flags.api.out, output, 0644); err != nil {
+            whisk.Debug(whisk.DbgError, "ioutil.WriteFile(%s) error: %s\n", flags.api.out, err)
+            errMsg := wski18n.T("Unable to write '{{.name}}': {{.err}}",
+                map[string]interface{}{"name": flags.api.out, "err": err})
+            whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        fmt.Fprintf(color.Output,
+            wski18n.T("{{.ok}} exported API {{.basepath}} to {{.name}}\n",
+                map[string]interface{}{
+                    "ok": color.GreenString("ok:"),
+                    "basepath": boldString(swagger.BasePath),
+                    "name": flags.api.out,
+                }))
+
+        return nil
+    },
+}
+
+var apiCallCmd = &cobra.Command{
+    Use:           "call BASE_PATH API_PATH API_VERB [--data DATA] [--header NAME:VALUE] [--expect-status CODE]",
+    Short:         wski18n.T("call an API through its managed URL"),
+    SilenceUsage:  true,
+    SilenceErrors: true,
+    PreRunE:       setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        var body io.Reader
+
+        if whiskErr := checkArgs(args, 3, 3, "Api call",
+            wski18n.T("An API base path, an API path, and an API verb are required.")); whiskErr != nil {
+            return whiskErr
+        }
+        if whiskErr, ok := isValidRelpath(args[1]); !ok {
+            return whiskErr
+        }
+        if whiskErr, ok := IsValidApiVerb(args[2]); !ok {
+            return whiskErr
+        }
+
+        headers, err := parseApiCallHeaders(flags.api.headers)
+        if err != nil {
+            return err
+        }
+
+        data := flags.api.data
+        if strings.HasPrefix(data, "@") {
+            if data, err = readFile(data[1:]); err != nil {
+                return err
+            }
+        }
+        if len(data) > 0 {
+            body = strings.NewReader(data)
+            if len(headers.Get("Content-Type")) == 0 && isValidJSON(data) {
+                headers.Set("Content-Type", "application/json")
+            }
+        }
+
+        retApi, err := getApiConfiguration(args[0])
+        if err != nil {
+            return err
+        }
+
+        managedUrl := getManagedUrl(retApi, args[1], args[2])
+        if (len(managedUrl) == 0) {
+            whisk.Debug(whisk.DbgError, "No managed URL for %s %s\n", args[1], args[2])
+            errMsg := wski18n.T("API {{.path}} {{.verb}} does not exist for basepath {{.basepath}}",
+                map[string]interface{}{"path": args[1], "verb": strings.ToUpper(args[2]), "basepath": args[0]})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        req, err := http.NewRequest(strings.ToUpper(args[2]), managedUrl, body)
+        if err != nil {
+            whisk.Debug(whisk.DbgError, "http.NewRequest(%s, %s) error: %s\n", args[2], managedUrl, err)
+            errMsg := wski18n.T("Unable to create HTTP request for {{.verb}} '{{.url}}': {{.err}}",
+                map[string]interface{}{"verb": strings.ToUpper(args[2]), "url": managedUrl, "err": err})
+            whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return whiskErr
+        }
+        req.Header = headers
+
+        // http.DefaultClient honors --insecure; whisk.NewClient configures its transport
+        resp, err := http.DefaultClient.Do(req)
+        if err != nil {
+            whisk.Debug(whisk.DbgError, "HTTP %s %s error: %s\n", req.Method, managedUrl, err)
+            errMsg := wski18n.T("Unable to call API {{.url}}: {{.err}}",
+                map[string]interface{}{"url": managedUrl, "err": err})
+            whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_NETWORK,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return whiskErr
+        }
+        defer resp.Body.Close()
+
+        respBody, err := ioutil.ReadAll(resp.Body)
+        if err != nil {
+            whisk.Debug(whisk.DbgError, "ioutil.ReadAll() error: %s\n", err)
+            errMsg := wski18n.T("Unable to call API {{.url}}: {{.err}}",
+                map[string]interface{}{"url": managedUrl, "err": err})
+            whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_NETWORK,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        printApiCallResponse(resp, respBody)
+
+        if (flags.api.expectStatus != 0 && resp.StatusCode != flags.api.expectStatus) {
+            whisk.Debug(whisk.DbgError, "Expected status %d, received %d\n", flags.api.expectStatus, resp.StatusCode)
+            errMsg := wski18n.T("Expected HTTP status {{.expected}} but received {{.status}}",
+                map[string]interface{}{"expected": flags.api.expectStatus, "status": resp.StatusCode})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        return nil
+    },
+}
+
 var apiDeleteCmd = &cobra.Command{
     Use:           "delete BASE_PATH | API_NAME [API_PATH [API_VERB]]",
     Short:         wski18n.T("delete an API"),
@@ -652,6 +942,20 @@ func parseSwaggerApi() (*whisk.Api, error) {
         return nil, whiskErr
     }
 
+    // The API gateway only accepts JSON, so YAML configuration files are converted first
+    if isYamlFile(flags.api.configfile) {
+        swaggerJSON, err := yamlToJSON([]byte(swagger))
+        if ( err != nil ) {
+            whisk.Debug(whisk.DbgError, "YAML parse of `%s' error: %s\n", flags.api.configfile, err)
+            errMsg := wski18n.T("Error parsing swagger file '{{.name}}': {{.err}}",
+                    map[string]interface{}{"name": flags.api.configfile, "err": err})
+            whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
+            return nil, whiskErr
+        }
+        swagger = string(swaggerJSON)
+    }
+
     // Parse the JSON into a swagger object
     swaggerObj := new(whisk.ApiSwagger)
     err = json.Unmarshal([]byte(swagger), swaggerObj)
@@ -728,6 +1032,357 @@ func isValidRelpath(relpath string) (error, bool) {
     return nil, true
 }
 
+/*
+ * Retrieve the configuration, including the swagger, of the API with the given base path or API name
+ */
+func getApiConfiguration(basepathOrApiName string) (*whisk.RetApi, error) {
+    api := new(whisk.Api)
+    options := new(whisk.ApiListOptions)
+    options.ApiBasePath = basepathOrApiName
+
+    retApiArray, _, err := client.Apis.Get(api, options)
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "client.Apis.Get(%s) error: %s\n", basepathOrApiName, err)
+        errMsg := wski18n.T("Unable to get API: {{.err}}", map[string]interface{}{"err": err})
+        whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return nil, whiskErr
+    }
+
+    if (retApiArray.Apis == nil || len(retApiArray.Apis) == 0 || retApiArray.Apis[0].ApiValue == nil ||
+        retApiArray.Apis[0].ApiValue.Swagger == nil) {
+        whisk.Debug(whisk.DbgError, "No swagger returned for '%s'\n", basepathOrApiName)
+        errMsg := wski18n.T("API does not exist for basepath {{.basepath}}",
+            map[string]interface{}{"basepath": basepathOrApiName})
+        whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return nil, whiskErr
+    }
+
+    return retApiArray.Apis[0].ApiValue, nil
+}
+
+/*
+ * Move the swagger operation for relpath and verb to newRelpath and newVerb, and return it
+ */
+func moveApiOperation(swagger *whisk.ApiSwagger, relpath string, verb string, newRelpath string,
+    newVerb string) (map[string]map[string]interface{}, error) {
+    operation, ok := swagger.Paths[relpath][verb]
+    if !ok {
+        whisk.Debug(whisk.DbgError, "Operation %s %s not found in swagger %#v\n", relpath, verb, swagger)
+        errMsg := wski18n.T("API {{.path}} {{.verb}} does not exist for basepath {{.basepath}}",
+            map[string]interface{}{"path": relpath, "verb": strings.ToUpper(verb), "basepath": swagger.BasePath})
+        whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return nil, whiskErr
+    }
+
+    if relpath == newRelpath && verb == newVerb {
+        return operation, nil
+    }
+
+    if _, exists := swagger.Paths[newRelpath][newVerb]; exists {
+        whisk.Debug(whisk.DbgError, "Operation %s %s already exists\n", newRelpath, newVerb)
+        errMsg := wski18n.T("API {{.path}} {{.verb}} already exists for basepath {{.basepath}}",
+            map[string]interface{}{"path": newRelpath, "verb": strings.ToUpper(newVerb), "basepath": swagger.BasePath})
+        whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return nil, whiskErr
+    }
+
+    delete(swagger.Paths[relpath], verb)
+    if len(swagger.Paths[relpath]) == 0 {
+        delete(swagger.Paths, relpath)
+    }
+    if swagger.Paths[newRelpath] == nil {
+        swagger.Paths[newRelpath] = make(map[string]map[string]map[string]interface{})
+    }
+    swagger.Paths[newRelpath][newVerb] = operation
+
+    return operation, nil
+}
+
+/*
+ * Point the swagger operation at a different backend action
+ */
+func setApiOperationAction(operation map[string]map[string]interface{}, qName QualifiedName) {
+    if operation["x-ibm-op-ext"] == nil {
+        operation["x-ibm-op-ext"] = make(map[string]interface{})
+    }
+
+    operation["x-ibm-op-ext"]["actionName"] = qName.entityName
+    operation["x-ibm-op-ext"]["actionNamespace"] = qName.namespace
+    operation["x-ibm-op-ext"]["backendMethod"] = "POST"
+    operation["x-ibm-op-ext"]["backendUrl"] = "https://" + client.Config.Host + "/api/v1/namespaces/" +
+        qName.namespace + "/actions/" + qName.entityName
+}
+
+/*
+ * Parse NAME:VALUE headers.  The slice flag splits values on commas, so an entry without a colon is
+ * rejoined with the header before it
+ */
+func parseApiCallHeaders(values []string) (http.Header, error) {
+    headers := make(http.Header)
+    var name string
+
+    for _, value := range values {
+        if i := strings.Index(value, ":"); i > 0 {
+            name = strings.TrimSpace(value[:i])
+            headers.Add(name, strings.TrimSpace(value[i+1:]))
+        } else if len(name) > 0 {
+            last := len(headers[http.CanonicalHeaderKey(name)]) - 1
+            headers[http.CanonicalHeaderKey(name)][last] += "," + value
+        } else {
+            whisk.Debug(whisk.DbgError, "Invalid header '%s'\n", value)
+            errMsg := wski18n.T("'{{.header}}' is not a valid header; use the NAME:VALUE format",
+                map[string]interface{}{"header": value})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
+            return nil, whiskErr
+        }
+    }
+
+    return headers, nil
+}
+
+func printApiCallResponse(resp *http.Response, body []byte) {
+    status := resp.Status
+    if (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+        status = color.GreenString(status)
+    } else {
+        status = color.RedString(status)
+    }
+    fmt.Fprintf(color.Output, "%s %s\n", resp.Proto, status)
+
+    names := make([]string, 0, len(resp.Header))
+    for name, _ := range resp.Header {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        for _, value := range resp.Header[name] {
+            fmt.Fprintf(color.Output, "%s: %s\n", boldString(name), value)
+        }
+    }
+
+    fmt.Println()
+    fmt.Println(strings.TrimSuffix(string(body), "\n"))
+}
+
+/*
+ * Parse a rate limit such as 100/min into the number of calls allowed per time unit
+ */
+func parseApiRateLimit(value string) (*whisk.ApiRateLimit, error) {
+    limit := new(whisk.ApiRateLimit)
+
+    parts := strings.SplitN(value, "/", 2)
+    if (len(parts) == 2) {
+        switch strings.ToLower(parts[1]) {
+        case "s", "sec", "second":
+            limit.Unit = "second"
+        case "m", "min", "minute":
+            limit.Unit = "minute"
+        case "h", "hour":
+            limit.Unit = "hour"
+        case "d", "day":
+            limit.Unit = "day"
+        }
+    }
+
+    rate, err := strconv.Atoi(parts[0])
+    if (err != nil || rate <= 0 || !whisk.ApiRateLimitUnits[limit.Unit]) {
+        whisk.Debug(whisk.DbgError, "Invalid rate limit '%s'\n", value)
+        errMsg := wski18n.T("'{{.limit}}' is not a valid rate limit; use a value such as 100/min or 10/s",
+            map[string]interface{}{"limit": value})
+        whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.DISPLAY_USAGE)
+        return nil, whiskErr
+    }
+    limit.Rate = rate
+
+    return limit, nil
+}
+
+func hasApiPolicyFlags() bool {
+    return flags.api.cors || flags.api.requireApiKey || len(flags.api.rateLimit) > 0
+}
+
+/*
+ * Apply the policy flags to the operation that was just created from arguments and update the API with them
+ */
+func updateApiPolicies(retApi *whisk.RetApi, api *whisk.Api, rateLimit *whisk.ApiRateLimit) (*whisk.RetApi, error) {
+    if (retApi.Swagger == nil) {
+        whisk.Debug(whisk.DbgError, "No swagger returned for the created API %s\n", api.GatewayBasePath)
+        errMsg := wski18n.T("The API gateway did not return the API configuration")
+        whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+            whisk.NO_DISPLAY_USAGE)
+        return nil, whiskErr
+    }
+
+    applyApiPolicies(retApi.Swagger, api.GatewayRelPath, api.GatewayMethod, rateLimit)
+    policyApi := new(whisk.Api)
+    policyApi.Namespace = client.Config.Namespace
+    var err error
+    if policyApi.Swagger, err = marshalApiSwagger(retApi.Swagger); err != nil {
+        return nil, err
+    }
+    sendApi := new(whisk.SendApi)
+    sendApi.ApiDoc = policyApi
+
+    retApi, _, err = client.Apis.Insert(sendApi, true)
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "client.Apis.Insert(%#v, true) error: %s\n", policyApi, err)
+        return nil, err
+    }
+
+    return retApi, nil
+}
+
+/*
+ * Delete the operation created from arguments when its policies could not be applied, so that it is not left
+ * exposed without them.  The returned error tells whether the operation is still there
+ */
+func removeCreatedApi(api *whisk.Api, policyErr error) error {
+    path := strings.TrimSuffix(api.GatewayBasePath, "/")+api.GatewayRelPath
+    options := &whisk.ApiOptions{
+        ApiBasePath: api.GatewayBasePath,
+        ApiRelPath: api.GatewayRelPath,
+        ApiVerb: api.GatewayMethod,
+        Force: true,
+    }
+
+    var errMsg string
+    if _, err := client.Apis.Delete(new(whisk.Api), options); err != nil {
+        whisk.Debug(whisk.DbgError, "client.Apis.Delete(%#v) error: %s\n", options, err)
+        errMsg = wski18n.T("Unable to apply the API policies: {{.err}}\nThe API {{.path}} {{.verb}} was created without its policies and could not be deleted: {{.delErr}}",
+            map[string]interface{}{"err": policyErr, "path": path, "verb": api.GatewayMethod, "delErr": err})
+    } else {
+        errMsg = wski18n.T("Unable to apply the API policies: {{.err}}\nThe API {{.path}} {{.verb}} was not created",
+            map[string]interface{}{"err": policyErr, "path": path, "verb": api.GatewayMethod})
+    }
+
+    whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), policyErr, whisk.EXITCODE_ERR_NETWORK,
+        whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    return whiskErr
+}
+
+/*
+ * Apply the CORS, API key, and rate limit flags to the swagger.  The rate limit is applied to the
+ * operation for relpath and verb, or to every operation when relpath is empty
+ */
+func applyApiPolicies(swagger *whisk.ApiSwagger, relpath string, verb string, rateLimit *whisk.ApiRateLimit) {
+    if (flags.api.cors) {
+        swagger.SetCors(true)
+    }
+    if (flags.api.requireApiKey) {
+        swagger.RequireApiKey()
+    }
+    if (rateLimit == nil) {
+        return
+    }
+
+    if (len(relpath) > 0) {
+        swagger.SetRateLimit(relpath, verb, rateLimit)
+        return
+    }
+    for path, operations := range swagger.Paths {
+        for op, _ := range operations {
+            swagger.SetRateLimit(path, op, rateLimit)
+        }
+    }
+}
+
+func marshalApiSwagger(swagger *whisk.ApiSwagger) (string, error) {
+    swaggerJSON, err := json.Marshal(swagger)
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "json.Marshal(%#v) error: %s\n", swagger, err)
+        errMsg := wski18n.T("Unable to serialize the API configuration: {{.err}}", map[string]interface{}{"err": err})
+        whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return "", whiskErr
+    }
+
+    return string(swaggerJSON), nil
+}
+
+/*
+ * Fill in the Swagger 2.0 fields the gateway may leave out so the exported document is standalone
+ */
+func getExportSwagger(swagger *whisk.ApiSwagger) *whisk.ApiSwagger {
+    export := *swagger
+
+    if (len(export.SwaggerName) == 0) {
+        export.SwaggerName = "2.0"
+    }
+    if (export.Info == nil) {
+        export.Info = new(whisk.ApiSwaggerInfo)
+    } else {
+        info := *export.Info
+        export.Info = &info
+    }
+    if (len(export.Info.Title) == 0) {
+        export.Info.Title = export.BasePath
+    }
+    if (len(export.Info.Version) == 0) {
+        export.Info.Version = "1.0.0"
+    }
+    if (export.Paths == nil) {
+        export.Paths = make(map[string]map[string]map[string]map[string]interface{})
+    }
+
+    return &export
+}
+
+func isYamlFile(filename string) bool {
+    ext := strings.ToLower(filepath.Ext(filename))
+    return ext == ".yaml" || ext == ".yml"
+}
+
+/*
+ * Convert a YAML document into JSON.  yaml.v2 decodes mappings with interface{} keys, which
+ * encoding/json cannot marshal, so the keys are converted to strings first
+ */
+func yamlToJSON(data []byte) ([]byte, error) {
+    var value interface{}
+
+    if err := yaml.Unmarshal(data, &value); err != nil {
+        return nil, err
+    }
+
+    return json.Marshal(convertYamlValue(value))
+}
+
+func convertYamlValue(value interface{}) interface{} {
+    switch value := value.(type) {
+    case map[interface{}]interface{}:
+        converted := make(map[string]interface{})
+        for key, val := range value {
+            converted[fmt.Sprint(key)] = convertYamlValue(val)
+        }
+        return converted
+    case []interface{}:
+        for i, val := range value {
+            value[i] = convertYamlValue(val)
+        }
+        return value
+    }
+
+    return value
+}
+
+/*
+ * Convert a JSON document into YAML, keeping the key order of the JSON document
+ */
+func jsonToYaml(data []byte) ([]byte, error) {
+    var value yaml.MapSlice
+
+    if err := yaml.Unmarshal(data, &value); err != nil {
+        return nil, err
+    }
+
+    return yaml.Marshal(value)
+}
+
 /*
  * Pull the managedUrl (external API URL) from the API configuration
  */
@@ -756,22 +1411,36 @@ func getManagedUrl(api *whisk.RetApi, relpath string, operation string) (url str
 
 func init() {
     apiCreateCmd.Flags().StringVarP(&flags.api.apiname, "apiname", "n", "", wski18n.T("Friendly name of the API; ignored when CFG_FILE is specified (default BASE_PATH)"))
-    apiCreateCmd.Flags().StringVarP(&flags.api.configfile, "config-file", "c", "", wski18n.T("`CFG_FILE` containing API configuration in swagger JSON format"))
+    apiCreateCmd.Flags().StringVarP(&flags.api.configfile, "config-file", "c", "", wski18n.T("`CFG_FILE` containing API configuration in swagger JSON or YAML format"))
+
+    // No shorthands for --action and --path; -a and -p are reserved for annotations and parameters
+    apiCreateCmd.Flags().BoolVar(&flags.api.cors, "cors", false, wski18n.T("enable CORS for the API"))
+    apiCreateCmd.Flags().StringVar(&flags.api.rateLimit, "rate-limit", "", wski18n.T("limit calls to each API operation to `RATE`, such as 100/min"))
+    apiCreateCmd.Flags().BoolVar(&flags.api.requireApiKey, "require-api-key", false, wski18n.T("require an API key in the {{.header}} header", map[string]interface{}{"header": whisk.ApiKeyHeader}))
 
-    //apiUpdateCmd.Flags().StringVarP(&flags.api.action, "action", "a", "", wski18n.T("`ACTION` to invoke when API is called"))
-    //apiUpdateCmd.Flags().StringVarP(&flags.api.path, "path", "p", "", wski18n.T("relative `PATH` of API"))
-    //apiUpdateCmd.Flags().StringVarP(&flags.api.verb, "method", "m", "", wski18n.T("API `VERB`"))
+    apiUpdateCmd.Flags().StringVar(&flags.api.action, "action", "", wski18n.T("`ACTION` to invoke when API is called"))
+    apiUpdateCmd.Flags().StringVar(&flags.api.path, "path", "", wski18n.T("relative `PATH` of API"))
+    apiUpdateCmd.Flags().StringVarP(&flags.api.verb, "method", "m", "", wski18n.T("API `VERB`"))
 
     apiGetCmd.Flags().BoolVarP(&flags.common.detail, "full", "f", false, wski18n.T("display full API configuration details"))
 
+    apiExportCmd.Flags().StringVar(&flags.api.format, "format", "json", wski18n.T("export `FORMAT`: json or yaml"))
+    apiExportCmd.Flags().StringVarP(&flags.api.out, "out", "o", "", wski18n.T("write the API to `FILE` instead of stdout"))
+
+    apiCallCmd.Flags().StringVar(&flags.api.data, "data", "", wski18n.T("request body `DATA`, or @FILE to read it from a file"))
+    apiCallCmd.Flags().StringSliceVarP(&flags.api.headers, "header", "H", []string{}, wski18n.T("request header in `NAME:VALUE` format"))
+    apiCallCmd.Flags().IntVar(&flags.api.expectStatus, "expect-status", 0, wski18n.T("fail unless the response has HTTP status `CODE`"))
+
     apiListCmd.Flags().IntVarP(&flags.common.skip, "skip", "s", 0, wski18n.T("exclude the first `SKIP` number of actions from the result"))
     apiListCmd.Flags().IntVarP(&flags.common.limit, "limit", "l", 30, wski18n.T("only return `LIMIT` number of actions from the collection"))
     apiListCmd.Flags().BoolVarP(&flags.common.full, "full", "f", false, wski18n.T("display full description of each API"))
 
     apiCmd.AddCommand(
         apiCreateCmd,
-        //apiUpdateCmd,
+        apiUpdateCmd,
         apiGetCmd,
+        apiExportCmd,
+        apiCallCmd,
         apiDeleteCmd,
         apiListCmd,
     )
@@ -0,0 +1,288 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "path"
+    "regexp"
+    "sort"
+    "strings"
+    "sync"
+
+    "github.com/fatih/color"
+    "github.com/mattn/go-colorable"
+
+    "../../go-whisk/whisk"
+    "../wski18n"
+)
+
+// Number of entities requested per page when expanding a name pattern
+const BulkPageSize = 200
+
+// isNamePattern reports whether an entity name is a glob pattern, such as ci-*/test-*, or a regular expression
+// when --regex is given
+func isNamePattern(name string) bool {
+    return flags.bulk.regex || strings.ContainsAny(name, "*?[")
+}
+
+// expandNamePattern lists the entities of a kind in the current namespace and returns the names that match
+func expandNamePattern(kind string, pattern string) ([]string, error) {
+    var matched []string
+    var nameRegexp *regexp.Regexp
+    var err error
+
+    if flags.bulk.regex {
+        if nameRegexp, err = regexp.Compile("^(?:" + pattern + ")$"); err != nil {
+            whisk.Debug(whisk.DbgError, "regexp.Compile(%s) failed: %s\n", pattern, err)
+            errStr := wski18n.T("'{{.pattern}}' is not a valid regular expression: {{.err}}",
+                    map[string]interface{}{"pattern": pattern, "err": err})
+            return nil, whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        }
+    } else if _, err = path.Match(pattern, ""); err != nil {
+        whisk.Debug(whisk.DbgError, "path.Match(%s) failed: %s\n", pattern, err)
+        errStr := wski18n.T("'{{.pattern}}' is not a valid pattern: {{.err}}",
+                map[string]interface{}{"pattern": pattern, "err": err})
+        return nil, whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    }
+
+    names, err := listEntityNames(kind)
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "listEntityNames(%s) failed: %s\n", kind, err)
+        errStr := wski18n.T("Unable to obtain the list of entities for namespace '{{.namespace}}': {{.err}}",
+                map[string]interface{}{"namespace": getClientNamespace(), "err": err})
+        return nil, whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_NETWORK,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    }
+
+    for _, name := range names {
+        var match bool
+        if nameRegexp != nil {
+            match = nameRegexp.MatchString(name)
+        } else {
+            match, _ = path.Match(pattern, name)
+        }
+        if match {
+            matched = append(matched, name)
+        }
+    }
+
+    if len(matched) == 0 {
+        whisk.Debug(whisk.DbgError, "No %s matches '%s'\n", kind, pattern)
+        errStr := wski18n.T("No {{.kind}} in namespace '{{.namespace}}' matches '{{.pattern}}'.",
+                map[string]interface{}{"kind": kind, "namespace": getClientNamespace(), "pattern": pattern})
+        return nil, whisk.MakeWskError(errors.New(errStr), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+            whisk.NO_DISPLAY_USAGE)
+    }
+
+    sort.Strings(matched)
+    return matched, nil
+}
+
+// listEntityNames lists the names of every entity of a kind in the current namespace, a page at a time.  Action
+// names include their package.
+func listEntityNames(kind string) ([]string, error) {
+    var names []string
+
+    for skip := 0; ; skip += BulkPageSize {
+        var page []string
+
+        switch kind {
+        case "action":
+            actions, _, err := client.Actions.List("", &whisk.ActionListOptions{Limit: BulkPageSize, Skip: skip})
+            if err != nil {
+                return nil, err
+            }
+            for i := range actions {
+                page = append(page, getBackupActionName(&actions[i]))
+            }
+        case "package":
+            packages, _, err := client.Packages.List(&whisk.PackageListOptions{Limit: BulkPageSize, Skip: skip})
+            if err != nil {
+                return nil, err
+            }
+            for _, pkg := range packages {
+                page = append(page, pkg.Name)
+            }
+        case "trigger":
+            triggers, _, err := client.Triggers.List(&whisk.TriggerListOptions{Limit: BulkPageSize, Skip: skip})
+            if err != nil {
+                return nil, err
+            }
+            for _, trigger := range triggers {
+                page = append(page, trigger.Name)
+            }
+        case "rule":
+            rules, _, err := client.Rules.List(&whisk.RuleListOptions{Limit: BulkPageSize, Skip: skip})
+            if err != nil {
+                return nil, err
+            }
+            for _, rule := range rules {
+                page = append(page, rule.Name)
+            }
+        }
+
+        names = append(names, page...)
+        if len(page) < BulkPageSize {
+            return names, nil
+        }
+    }
+}
+
+/*
+Run an operation on each named entity with at most --concurrency operations at a time.  Every result is reported
+as it completes, numbered to show the progress; done returns the message of a successful operation.  The
+operations are all attempted, and an error is returned when any of them failed.
+*/
+func runBulk(names []string, run func(name string) error, done func(name string) string) error {
+    var mutex sync.Mutex
+    var wg sync.WaitGroup
+    var completed, failed int
+
+    concurrency := flags.bulk.concurrency
+    if concurrency < 1 {
+        concurrency = 1
+    }
+    if concurrency > len(names) {
+        concurrency = len(names)
+    }
+    queue := make(chan string)
+
+    report := func(name string, err error) {
+        mutex.Lock()
+        defer mutex.Unlock()
+
+        completed++
+        progress := fmt.Sprintf("[%*d/%d]", len(fmt.Sprint(len(names))), completed, len(names))
+        if err != nil {
+            failed++
+            fmt.Fprintf(colorable.NewColorableStderr(), "%s %s %s\n", progress, color.RedString(wski18n.T("error:")), err)
+        } else {
+            fmt.Fprintf(color.Output, "%s %s", progress, done(name))
+        }
+    }
+
+    for i := 0; i < concurrency; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for name := range queue {
+                report(name, run(name))
+            }
+        }()
+    }
+
+    for _, name := range names {
+        queue <- name
+    }
+    close(queue)
+    wg.Wait()
+
+    if failed > 0 {
+        whisk.Debug(whisk.DbgError, "%d of %d operations failed\n", failed, len(names))
+        errStr := wski18n.T("{{.failed}} of {{.count}} entities failed",
+                map[string]interface{}{"failed": failed, "count": len(names)})
+        return whisk.MakeWskError(errors.New(errStr), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+            whisk.NO_DISPLAY_USAGE)
+    }
+
+    return nil
+}
+
+// deleteEntityPattern confirms and deletes the entities of a kind whose names match a pattern.  When related is
+// given, it lists what is deleted along with the matched entities for the confirmation.
+func deleteEntityPattern(kind string, pattern string, related func(names []string) ([]string, error),
+        run func(name string) error, done func(name string) string) error {
+    names, err := expandNamePattern(kind, pattern)
+    if err != nil {
+        return err
+    }
+
+    var items []string
+    for _, name := range names {
+        items = append(items, kind + " " + name)
+    }
+    if related != nil {
+        relatedItems, err := related(names)
+        if err != nil {
+            return err
+        }
+        items = append(items, relatedItems...)
+    }
+    if err = confirmDelete(items); err != nil {
+        return err
+    }
+
+    // The confirmation covers anything deleted along with each entity
+    flags.common.yes = true
+
+    return runBulk(names, run, done)
+}
+
+/*
+Delete a trigger along with the feed it receives events from.  The DELETE lifecycle event is sent to the feed
+action through a client of its own, so that the namespace of the shared client is left alone while other
+triggers are being deleted.
+*/
+func deleteTriggerAndFeed(triggerName string) error {
+    trigger, _, err := client.Triggers.Delete(triggerName)
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "client.Triggers.Delete(%s) failed: %s\n", triggerName, err)
+        errStr := wski18n.T("Unable to delete trigger '{{.name}}': {{.err}}",
+                map[string]interface{}{"name": triggerName, "err": err})
+        return whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    }
+
+    feed := ""
+    if trigger != nil {
+        feed = getValueString(trigger.Annotations, "feed")
+    }
+    if len(feed) == 0 {
+        return nil
+    }
+
+    feedQName, err := parseQualifiedName(feed)
+    if err == nil {
+        config := *client.Config
+        config.Namespace = feedQName.namespace
+
+        var feedClient *whisk.Client
+        if feedClient, err = whisk.NewClient(http.DefaultClient, &config); err == nil {
+            params := map[string]interface{}{
+                "lifecycleEvent": "DELETE",
+                "triggerName": fmt.Sprintf("/%s/%s", client.Namespace, triggerName),
+                "authKey": client.Config.AuthToken,
+            }
+            _, _, err = feedClient.Actions.Invoke(feedQName.entityName, params, true, false)
+        }
+    }
+
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "Invoke of feed action '%s' failed: %s\n", feed, err)
+        errStr := wski18n.T("Unable to invoke trigger '{{.trigname}}' feed action '{{.feedname}}'; feed is not configured: {{.err}}",
+                map[string]interface{}{"trigname": triggerName, "feedname": feed, "err": err})
+        return whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    }
+
+    return nil
+}
@@ -0,0 +1,599 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "../../go-whisk/whisk"
+    "../wski18n"
+
+    "github.com/fatih/color"
+    "github.com/mattn/go-colorable"
+    "github.com/spf13/cobra"
+    "gopkg.in/yaml.v2"
+)
+
+const DefaultManifest = "manifest.yaml"
+
+// Manifest declares the entities of an application.  Entities at the top level are created in the default
+// package; packages may declare their own actions and sequences.
+type Manifest struct {
+    Packages    map[string]*ManifestPackage     `yaml:"packages"`
+    Actions     map[string]*ManifestAction      `yaml:"actions"`
+    Sequences   map[string]*ManifestSequence    `yaml:"sequences"`
+    Triggers    map[string]*ManifestTrigger     `yaml:"triggers"`
+    Rules       map[string]*ManifestRule        `yaml:"rules"`
+    Apis        []*ManifestApi                  `yaml:"apis"`
+}
+
+type ManifestPackage struct {
+    Bind        string                          `yaml:"bind"`          // package the binding refers to
+    Publish     bool                            `yaml:"publish"`
+    Parameters  map[string]interface{}          `yaml:"parameters"`
+    Annotations map[string]interface{}          `yaml:"annotations"`
+    Actions     map[string]*ManifestAction      `yaml:"actions"`
+    Sequences   map[string]*ManifestSequence    `yaml:"sequences"`
+}
+
+type ManifestAction struct {
+    Function    string                          `yaml:"function"`      // code path, relative to the manifest
+    Docker      string                          `yaml:"docker"`        // image of a docker action
+    Kind        string                          `yaml:"kind"`
+    Main        string                          `yaml:"main"`
+    Limits      *ManifestLimits                 `yaml:"limits"`
+    Parameters  map[string]interface{}          `yaml:"parameters"`
+    Annotations map[string]interface{}          `yaml:"annotations"`
+}
+
+type ManifestLimits struct {
+    Timeout     int                             `yaml:"timeout"`
+    Memory      int                             `yaml:"memory"`
+    Logsize     int                             `yaml:"logsize"`
+}
+
+type ManifestSequence struct {
+    Actions     []string                        `yaml:"actions"`
+    Parameters  map[string]interface{}          `yaml:"parameters"`
+    Annotations map[string]interface{}          `yaml:"annotations"`
+}
+
+type ManifestTrigger struct {
+    Feed        string                          `yaml:"feed"`
+    Parameters  map[string]interface{}          `yaml:"parameters"`    // passed to the feed when there is one
+    Annotations map[string]interface{}          `yaml:"annotations"`
+}
+
+type ManifestRule struct {
+    Trigger     string                          `yaml:"trigger"`
+    Action      string                          `yaml:"action"`
+}
+
+type ManifestApi struct {
+    Name        string                          `yaml:"name"`
+    BasePath    string                          `yaml:"basepath"`
+    Path        string                          `yaml:"path"`
+    Verb        string                          `yaml:"verb"`
+    Action      string                          `yaml:"action"`
+}
+
+// ManifestStep is one entity of a manifest.  Steps are ordered so that every entity follows the entities it
+// depends on.
+type ManifestStep struct {
+    Kind        string
+    Name        string
+    Package     *ManifestPackage
+    Action      *ManifestAction
+    Sequence    *ManifestSequence
+    Trigger     *ManifestTrigger
+    Rule        *ManifestRule
+    Api         *ManifestApi
+}
+
+var deployCmd = &cobra.Command{
+    Use:   "deploy [--manifest MANIFEST]",
+    Short: wski18n.T("create or update the entities declared in a manifest"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if whiskErr := checkArgs(args, 0, 0, "Deploy", wski18n.T("No arguments are required.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        manifest, err := readManifest(flags.deploy.manifest)
+        if err != nil {
+            return err
+        }
+
+        steps, err := getManifestSteps(manifest)
+        if err != nil {
+            return err
+        }
+
+        for _, step := range steps {
+            if err = deployManifestStep(step, filepath.Dir(flags.deploy.manifest)); err != nil {
+                whisk.Debug(whisk.DbgError, "deployManifestStep(%s %s) failed: %s\n", step.Kind, step.Name, err)
+                errStr := wski18n.T("Unable to deploy {{.kind}} '{{.name}}': {{.err}}",
+                        map[string]interface{}{"kind": step.Kind, "name": step.Name, "err": err})
+                werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+                    whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+                return werr
+            }
+
+            fmt.Fprintf(color.Output,
+                wski18n.T("{{.ok}} deployed {{.kind}} {{.name}}\n",
+                    map[string]interface{}{"ok": color.GreenString("ok:"), "kind": step.Kind, "name": boldString(step.Name)}))
+        }
+
+        return nil
+    },
+}
+
+var undeployCmd = &cobra.Command{
+    Use:   "undeploy [--manifest MANIFEST]",
+    Short: wski18n.T("delete the entities declared in a manifest"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if whiskErr := checkArgs(args, 0, 0, "Undeploy", wski18n.T("No arguments are required.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        manifest, err := readManifest(flags.deploy.manifest)
+        if err != nil {
+            return err
+        }
+
+        steps, err := getManifestSteps(manifest)
+        if err != nil {
+            return err
+        }
+
+        // Dependents are deleted before the entities they depend on
+        for i := len(steps) - 1; i >= 0; i-- {
+            step := steps[i]
+
+            resp, err := undeployManifestStep(step)
+            if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+                fmt.Fprintf(colorable.NewColorableStderr(),
+                    wski18n.T("{{.warning}} {{.kind}} {{.name}} does not exist\n",
+                        map[string]interface{}{"warning": color.YellowString("warning:"), "kind": step.Kind, "name": step.Name}))
+                continue
+            } else if err != nil {
+                whisk.Debug(whisk.DbgError, "undeployManifestStep(%s %s) failed: %s\n", step.Kind, step.Name, err)
+                errStr := wski18n.T("Unable to undeploy {{.kind}} '{{.name}}': {{.err}}",
+                        map[string]interface{}{"kind": step.Kind, "name": step.Name, "err": err})
+                werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+                    whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+                return werr
+            }
+
+            fmt.Fprintf(color.Output,
+                wski18n.T("{{.ok}} undeployed {{.kind}} {{.name}}\n",
+                    map[string]interface{}{"ok": color.GreenString("ok:"), "kind": step.Kind, "name": boldString(step.Name)}))
+        }
+
+        return nil
+    },
+}
+
+func readManifest(filename string) (*Manifest, error) {
+    content, err := readFile(filename)
+    if err != nil {
+        return nil, err
+    }
+
+    manifest := new(Manifest)
+    if err = yaml.Unmarshal([]byte(content), manifest); err != nil {
+        whisk.Debug(whisk.DbgError, "yaml.Unmarshal(%s) error: %s\n", filename, err)
+        errStr := wski18n.T("Unable to parse manifest '{{.name}}': {{.err}}",
+                map[string]interface{}{"name": filename, "err": err})
+        werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return nil, werr
+    }
+
+    return manifest, nil
+}
+
+// getManifestSteps orders the manifest entities: packages, bindings, actions, sequences, triggers, rules,
+// and APIs.  A sequence follows any sequence of the manifest it is composed of.
+func getManifestSteps(manifest *Manifest) ([]ManifestStep, error) {
+    var steps []ManifestStep
+
+    packageNames := getSortedKeys(manifest.Packages)
+    for _, bindings := range []bool{false, true} {
+        for _, name := range packageNames {
+            if pkg := manifest.Packages[name]; pkg != nil && (len(pkg.Bind) > 0) == bindings {
+                steps = append(steps, ManifestStep{Kind: "package", Name: name, Package: pkg})
+            }
+        }
+    }
+
+    actions := make(map[string]*ManifestAction)
+    sequences := make(map[string]*ManifestSequence)
+    for name, action := range manifest.Actions {
+        actions[name] = action
+    }
+    for name, sequence := range manifest.Sequences {
+        sequences[name] = sequence
+    }
+    for _, pkgName := range packageNames {
+        pkg := manifest.Packages[pkgName]
+        if pkg == nil {
+            continue
+        }
+        if len(pkg.Bind) > 0 && (len(pkg.Actions) > 0 || len(pkg.Sequences) > 0) {
+            errStr := wski18n.T("Package binding '{{.name}}' cannot declare actions or sequences",
+                    map[string]interface{}{"name": pkgName})
+            return nil, whisk.MakeWskError(errors.New(errStr), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.NO_DISPLAY_USAGE)
+        }
+        for name, action := range pkg.Actions {
+            actions[pkgName + "/" + name] = action
+        }
+        for name, sequence := range pkg.Sequences {
+            sequences[pkgName + "/" + name] = sequence
+        }
+    }
+
+    for _, name := range getSortedKeys(actions) {
+        steps = append(steps, ManifestStep{Kind: "action", Name: name, Action: actions[name]})
+    }
+
+    added := make(map[string]bool)
+    var addSequence func(name string, depth int)
+    addSequence = func(name string, depth int) {
+        if added[name] || depth > len(sequences) {
+            return
+        }
+        for _, component := range sequences[name].Actions {
+            if sequences[component] != nil {
+                addSequence(component, depth + 1)
+            }
+        }
+        if !added[name] {
+            added[name] = true
+            steps = append(steps, ManifestStep{Kind: "sequence", Name: name, Sequence: sequences[name]})
+        }
+    }
+    for _, name := range getSortedKeys(sequences) {
+        addSequence(name, 0)
+    }
+
+    for _, name := range getSortedKeys(manifest.Triggers) {
+        steps = append(steps, ManifestStep{Kind: "trigger", Name: name, Trigger: manifest.Triggers[name]})
+    }
+    for _, name := range getSortedKeys(manifest.Rules) {
+        steps = append(steps, ManifestStep{Kind: "rule", Name: name, Rule: manifest.Rules[name]})
+    }
+    for _, api := range manifest.Apis {
+        name := strings.TrimSuffix(api.BasePath, "/") + api.Path + " " + strings.ToUpper(api.Verb)
+        steps = append(steps, ManifestStep{Kind: "API", Name: name, Api: api})
+    }
+
+    for _, step := range steps {
+        if step.Package == nil && step.Action == nil && step.Sequence == nil && step.Trigger == nil &&
+            step.Rule == nil && step.Api == nil {
+            errStr := wski18n.T("The manifest declares {{.kind}} '{{.name}}' without any properties",
+                    map[string]interface{}{"kind": step.Kind, "name": step.Name})
+            return nil, whisk.MakeWskError(errors.New(errStr), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.NO_DISPLAY_USAGE)
+        }
+    }
+
+    return steps, nil
+}
+
+// getSortedKeys returns the keys of a map with string keys in sorted order
+func getSortedKeys(value interface{}) []string {
+    var keys []string
+
+    switch value := value.(type) {
+    case map[string]*ManifestPackage:
+        for key, _ := range value {
+            keys = append(keys, key)
+        }
+    case map[string]*ManifestAction:
+        for key, _ := range value {
+            keys = append(keys, key)
+        }
+    case map[string]*ManifestSequence:
+        for key, _ := range value {
+            keys = append(keys, key)
+        }
+    case map[string]*ManifestTrigger:
+        for key, _ := range value {
+            keys = append(keys, key)
+        }
+    case map[string]*ManifestRule:
+        for key, _ := range value {
+            keys = append(keys, key)
+        }
+    }
+    sort.Strings(keys)
+
+    return keys
+}
+
+// getManifestKeyValues converts manifest parameters or annotations, as decoded from YAML, into key/value pairs
+func getManifestKeyValues(values map[string]interface{}) whisk.KeyValueArr {
+    keyValues := whisk.KeyValueArr{}
+
+    for _, key := range getSortedMapKeys(values) {
+        keyValues = append(keyValues, whisk.KeyValue{Key: key, Value: convertYamlValue(values[key])})
+    }
+
+    return keyValues
+}
+
+func getSortedMapKeys(values map[string]interface{}) []string {
+    var keys []string
+    for key, _ := range values {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+
+    return keys
+}
+
+func deployManifestStep(step ManifestStep, manifestDir string) error {
+    var err error
+
+    switch step.Kind {
+    case "package":
+        pkg := &whisk.Package{
+            Name: step.Name,
+            Publish: &step.Package.Publish,
+            Parameters: getManifestKeyValues(step.Package.Parameters),
+            Annotations: getManifestKeyValues(step.Package.Annotations),
+        }
+        if len(step.Package.Bind) > 0 {
+            bindQName, err := parseQualifiedName(step.Package.Bind)
+            if err != nil {
+                return err
+            }
+            pkg.Binding = &whisk.Binding{Namespace: bindQName.namespace, Name: bindQName.entityName}
+        }
+
+        client.Namespace = getNamespace()
+        _, _, err = client.Packages.Insert(pkg, true)
+
+    case "action":
+        action, err := parseManifestAction(step.Name, step.Action, manifestDir)
+        if err != nil {
+            return err
+        }
+        _, _, err = client.Actions.Insert(action, true)
+        return err
+
+    case "sequence":
+        action := &whisk.Action{
+            Name: step.Name,
+            Exec: &whisk.Exec{Kind: "sequence", Components: csvToQualifiedActions(strings.Join(step.Sequence.Actions, ","))},
+            Parameters: getManifestKeyValues(step.Sequence.Parameters),
+            Annotations: getManifestKeyValues(step.Sequence.Annotations),
+        }
+
+        client.Namespace = getNamespace()
+        _, _, err = client.Actions.Insert(action, true)
+
+    case "trigger":
+        err = deployManifestTrigger(step.Name, step.Trigger)
+
+    case "rule":
+        rule := &whisk.Rule{
+            Name: step.Name,
+            Trigger: getQualifiedName(step.Rule.Trigger, Properties.Namespace),
+            Action: getQualifiedName(step.Rule.Action, Properties.Namespace),
+        }
+
+        client.Namespace = getNamespace()
+        _, _, err = client.Rules.Insert(rule, true)
+
+    case "API":
+        args := []string{step.Api.Path, step.Api.Verb, step.Api.Action}
+        if len(step.Api.BasePath) > 0 {
+            args = append([]string{step.Api.BasePath}, args...)
+        }
+        flags.api.apiname = step.Api.Name
+
+        api, err := parseApi(nil, args)
+        if err != nil {
+            return err
+        }
+
+        sendApi := new(whisk.SendApi)
+        sendApi.ApiDoc = api
+        _, _, err = client.Apis.Insert(sendApi, false)
+        return err
+    }
+
+    return err
+}
+
+// parseManifestAction builds the action through parseAction so that manifest actions support the same kinds
+// and artifacts as action create.  Every limit is sent so that a redeploy resets the limits removed from the
+// manifest, but an empty list of parameters or annotations keeps those of the deployed action, so removing all
+// of them requires an undeploy first.
+func parseManifestAction(name string, manifestAction *ManifestAction, manifestDir string) (*whisk.Action, error) {
+    artifact := manifestAction.Function
+    if len(artifact) > 0 && !filepath.IsAbs(artifact) {
+        artifact = filepath.Join(manifestDir, artifact)
+    }
+
+    flags.action.kind = manifestAction.Kind
+    flags.action.main = manifestAction.Main
+    flags.action.docker = len(manifestAction.Docker) > 0
+    flags.action.sequence = false
+    flags.action.copy = false
+    flags.action.memory = MEMORY_LIMIT
+    flags.action.timeout = TIMEOUT_LIMIT
+    flags.action.logsize = LOGSIZE_LIMIT
+    flags.common.param = nil
+    flags.common.annotation = nil
+
+    if flags.action.docker && len(artifact) == 0 {
+        artifact = manifestAction.Docker
+    }
+
+    action, err := parseAction(nil, []string{name, artifact})
+    if err != nil {
+        return nil, err
+    }
+    if action.Exec == nil {
+        errStr := wski18n.T("Action '{{.name}}' requires a function or a docker image",
+                map[string]interface{}{"name": name})
+        return nil, whisk.MakeWskError(errors.New(errStr), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+            whisk.NO_DISPLAY_USAGE)
+    }
+    // parseAction runs zip artifacts of docker actions on the default skeleton image
+    if flags.action.docker && len(manifestAction.Function) > 0 && filepath.Ext(artifact) == ".zip" {
+        action.Exec.Image = manifestAction.Docker
+    }
+
+    memory, timeout, logsize := MEMORY_LIMIT, TIMEOUT_LIMIT, LOGSIZE_LIMIT
+    if limits := manifestAction.Limits; limits != nil {
+        if limits.Memory > 0 {
+            memory = limits.Memory
+        }
+        if limits.Timeout > 0 {
+            timeout = limits.Timeout
+        }
+        if limits.Logsize > 0 {
+            logsize = limits.Logsize
+        }
+    }
+    action.Limits = &whisk.Limits{Memory: &memory, Timeout: &timeout, Logsize: &logsize}
+    action.Parameters = getManifestKeyValues(manifestAction.Parameters)
+    action.Annotations = getManifestKeyValues(manifestAction.Annotations)
+
+    return action, nil
+}
+
+// deployManifestTrigger creates a trigger and configures its feed, or updates the annotations of an existing
+// trigger.  The feed of an existing trigger is left as is.
+func deployManifestTrigger(name string, manifestTrigger *ManifestTrigger) error {
+    client.Namespace = getNamespace()
+
+    trigger := &whisk.Trigger{
+        Name: name,
+        Annotations: getManifestKeyValues(manifestTrigger.Annotations),
+    }
+    if len(manifestTrigger.Feed) > 0 {
+        trigger.Annotations = append(trigger.Annotations, whisk.KeyValue{Key: "feed", Value: manifestTrigger.Feed})
+    } else {
+        trigger.Parameters = getManifestKeyValues(manifestTrigger.Parameters)
+    }
+
+    _, _, err := client.Triggers.Get(name)
+    exists := err == nil
+
+    if _, _, err = client.Triggers.Insert(trigger, exists); err != nil || exists || len(manifestTrigger.Feed) == 0 {
+        return err
+    }
+
+    feedQName, err := parseQualifiedName(manifestTrigger.Feed)
+    if err != nil {
+        return err
+    }
+
+    flags.common.param = nil
+    for _, key := range getSortedMapKeys(manifestTrigger.Parameters) {
+        value, err := json.Marshal(convertYamlValue(manifestTrigger.Parameters[key]))
+        if err != nil {
+            return err
+        }
+        flags.common.param = append(flags.common.param, getFormattedJSON(key, string(value)))
+    }
+    flags.common.param = append(flags.common.param, getFormattedJSON("lifecycleEvent", "CREATE"))
+    flags.common.param = append(flags.common.param, getFormattedJSON("triggerName", getQualifiedName(name, Properties.Namespace)))
+    flags.common.param = append(flags.common.param, getFormattedJSON("authKey", client.Config.AuthToken))
+
+    if err = configureFeed(name, fmt.Sprintf("/%s/%s", feedQName.namespace, feedQName.entityName)); err != nil {
+        // Delete the trigger that was created for this feed
+        client.Namespace = getNamespace()
+        if _, _, delErr := client.Triggers.Delete(name); delErr != nil {
+            whisk.Debug(whisk.DbgWarn, "Ignoring client.Triggers.Delete(%s) failure: %s\n", name, delErr)
+        }
+    }
+
+    return err
+}
+
+func undeployManifestStep(step ManifestStep) (*http.Response, error) {
+    var resp *http.Response
+    var err error
+
+    client.Namespace = getNamespace()
+
+    switch step.Kind {
+    case "package":
+        resp, err = client.Packages.Delete(step.Name)
+
+    case "action", "sequence":
+        resp, err = client.Actions.Delete(step.Name)
+
+    case "trigger":
+        var trigger *whisk.Trigger
+        trigger, resp, err = client.Triggers.Delete(step.Name)
+        if err != nil || trigger == nil {
+            return resp, err
+        }
+
+        if feed := getValueString(trigger.Annotations, "feed"); len(feed) > 0 {
+            flags.common.param = nil
+            flags.common.param = append(flags.common.param, getFormattedJSON("lifecycleEvent", "DELETE"))
+            flags.common.param = append(flags.common.param, getFormattedJSON("triggerName", getQualifiedName(step.Name, Properties.Namespace)))
+            flags.common.param = append(flags.common.param, getFormattedJSON("authKey", client.Config.AuthToken))
+            err = configureFeed(step.Name, feed)
+        }
+
+    case "rule":
+        // Active rules cannot be deleted
+        if _, resp, err = client.Rules.SetState(step.Name, "inactive"); err != nil {
+            return resp, err
+        }
+        resp, err = client.Rules.Delete(step.Name)
+
+    case "API":
+        options := new(whisk.ApiOptions)
+        options.Force = true
+        options.ApiBasePath = step.Api.BasePath
+        if len(options.ApiBasePath) == 0 {
+            options.ApiBasePath = "/"
+        }
+        options.ApiRelPath = step.Api.Path
+        options.ApiVerb = strings.ToUpper(step.Api.Verb)
+        resp, err = client.Apis.Delete(new(whisk.Api), options)
+    }
+
+    return resp, err
+}
+
+func init() {
+    deployCmd.Flags().StringVarP(&flags.deploy.manifest, "manifest", "m", DefaultManifest, wski18n.T("`MANIFEST` file declaring the entities to deploy"))
+    undeployCmd.Flags().StringVarP(&flags.deploy.manifest, "manifest", "m", DefaultManifest, wski18n.T("`MANIFEST` file declaring the entities to undeploy"))
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+    "errors"
+    "net/http"
+    "net/url"
+    "os"
+    "strings"
+
+    "github.com/mitchellh/go-homedir"
+
+    "../../go-whisk/whisk"
+    "../wski18n"
+)
+
+// Endpoint is a namespace on an API host, with a client connected to it
+type Endpoint struct {
+    Spec        string
+    Host        string
+    Auth        string
+    Namespace   string
+    Client      *whisk.Client
+}
+
+// getProfileFilePath returns the properties file of a named profile, such as ~/.wskprops.staging
+func getProfileFilePath(profile string) (string, error) {
+    return homedir.Expand(DefaultPropsFile + "." + profile)
+}
+
+/*
+Resolve an endpoint specification into an API host, authorization key, and namespace.  The specification is
+PROFILE[/NAMESPACE], where PROFILE names a properties file such as ~/.wskprops.staging, or HOST[/NAMESPACE].
+Hosts use the current authorization key.  An empty PROFILE or HOST means the current properties.
+
+Examples:
+      staging => host, auth and namespace of ~/.wskprops.staging
+      staging/team => host and auth of ~/.wskprops.staging, namespace team
+      https://openwhisk.example.com/team => host https://openwhisk.example.com, current auth, namespace team
+      /team => current host and auth, namespace team
+*/
+func getEndpoint(spec string) (*Endpoint, error) {
+    var name string
+
+    endpoint := &Endpoint{
+        Spec: spec,
+        Host: Properties.APIHost,
+        Auth: Properties.Auth,
+        Namespace: Properties.Namespace,
+    }
+
+    if strings.Contains(spec, "://") {
+        hostURL, err := url.Parse(spec)
+        if err != nil {
+            return nil, makeEndpointError(spec, err)
+        }
+        endpoint.Host = hostURL.Scheme + "://" + hostURL.Host
+        endpoint.Namespace = strings.Trim(hostURL.Path, "/")
+    } else {
+        parts := strings.SplitN(spec, "/", 2)
+        name = parts[0]
+        if len(parts) == 2 {
+            endpoint.Namespace = strings.Trim(parts[1], "/")
+        }
+    }
+
+    if len(name) > 0 {
+        profileFile, err := getProfileFilePath(name)
+        if err != nil {
+            return nil, makeEndpointError(spec, err)
+        }
+
+        if _, err = os.Stat(profileFile); err == nil {
+            props, err := readProps(profileFile)
+            if err != nil {
+                return nil, makeEndpointError(spec, err)
+            }
+
+            endpoint.Host = props["APIHOST"]
+            endpoint.Auth = props["AUTH"]
+            if namespace := props["NAMESPACE"]; len(namespace) > 0 && !strings.Contains(spec, "/") {
+                endpoint.Namespace = namespace
+            }
+        } else {
+            whisk.Debug(whisk.DbgInfo, "No profile '%s'; treating '%s' as an API host\n", profileFile, name)
+            endpoint.Host = name
+        }
+    }
+
+    if len(endpoint.Namespace) == 0 {
+        endpoint.Namespace = DefaultNamespace
+    }
+
+    baseURL, err := getURLBase(endpoint.Host)
+    if err != nil {
+        return nil, makeEndpointError(spec, err)
+    }
+
+    endpoint.Client, err = whisk.NewClient(http.DefaultClient, &whisk.Config{
+        AuthToken:  endpoint.Auth,
+        Namespace:  endpoint.Namespace,
+        BaseURL:    baseURL,
+        Version:    Properties.APIVersion,
+        Insecure:   flags.global.insecure,
+        Host:       endpoint.Host,
+    })
+    if err != nil {
+        return nil, makeEndpointError(spec, err)
+    }
+
+    whisk.Debug(whisk.DbgInfo, "Endpoint '%s' is namespace '%s' on '%s'\n", spec, endpoint.Namespace, endpoint.Host)
+    return endpoint, nil
+}
+
+func makeEndpointError(spec string, err error) error {
+    whisk.Debug(whisk.DbgError, "getEndpoint(%s) error: %s\n", spec, err)
+    errStr := wski18n.T("'{{.endpoint}}' is not a valid profile or API host: {{.err}}",
+            map[string]interface{}{"endpoint": spec, "err": err})
+    return whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+        whisk.DISPLAY_USAGE)
+}
+
+// withEndpoint runs fn with the commands' client connected to the endpoint
+func withEndpoint(endpoint *Endpoint, fn func() error) error {
+    savedClient := client
+    client = endpoint.Client
+    defer func() {
+        client = savedClient
+    }()
+
+    return fn()
+}
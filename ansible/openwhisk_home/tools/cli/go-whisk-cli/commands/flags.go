@@ -35,6 +35,7 @@ var flags struct {
         apihost     string
         apiversion  string
         insecure    bool
+        policy      string  // policy file that entities are checked against
     }
 
     common struct {
@@ -50,6 +51,12 @@ var flags struct {
         summary     bool
         feed        string  // name of feed
         detail      bool
+        yes         bool    // skip the confirmation of a delete
+        ifVersion   string  // update only if the entity is still at this version
+        paramMerge  bool    // merge the parameters of an update into the existing ones
+        paramRemove []string // parameters removed from the existing ones by an update
+        annotMerge  bool    // merge the annotations of an update into the existing ones
+        annotRemove []string // annotations removed from the existing ones by an update
     }
 
     property struct {
@@ -65,6 +72,8 @@ var flags struct {
         apihostSet      string
         apiversionSet   string
         namespaceSet    string
+        policy          bool
+        policySet       string
     }
 
     action struct {
@@ -79,18 +88,72 @@ var flags struct {
         result      bool
         kind        string
         main        string
+        concurrency int     // number of concurrent invocations
+        requests    int     // total number of invocations
+        rate        string  // maximum invocation rate, e.g. 200/s
+        out         string  // file the results are written to
+        batch       string  // file of NDJSON payloads to invoke the action with
+        failFast    bool    // stop a batch after the first failed invocation
+        runtimeURL  string  // URL of a locally started action runtime
+        container   string  // docker container of the local runtime, used to read the logs
+        cascade     bool    // also delete the rules and APIs that refer to a deleted action
+    }
+
+    // package
+    pkg struct {
+        recursive   bool    // delete the actions and feeds of a package before the package
     }
 
     activation struct {
         action          string // retrieve results for this action
-        upto            int64  // retrieve results up to certain time
-        since           int64  // retrieve results after certain time
+        upto            string // retrieve results up to certain time
+        since           string // retrieve results after certain time
         seconds         int    // stop polling for activation upda
         sinceSeconds    int
         sinceMinutes    int
         sinceHours      int
         sinceDays       int
         exit            int
+        groupBy         string  // group usage by action, package or namespace
+        format          string  // output format (csv or json)
+        price           float64 // price per GB-second
+        exportFormat    string  // export format (ndjson or csv)
+        out             string  // file the activations are exported to
+        status          string  // only include activations with this response status
+        minDuration     int64   // only include activations that ran at least this many milliseconds
+        maxDuration     int64   // only include activations that ran at most this many milliseconds
+        resultContains  string  // only include activations whose result contains this text
+        logsContains    string  // only include activations whose logs contain this text
+        cold            bool    // only include activations that initialized a new container
+    }
+
+    // bulk
+    bulk struct {
+        regex       bool    // match entity names against a regular expression instead of a glob pattern
+        concurrency int     // number of entities operated on at a time
+    }
+
+    // deploy
+    deploy struct {
+        manifest  string    // manifest declaring the entities to deploy or undeploy
+    }
+
+    // namespace
+    namespace struct {
+        out         string    // file the backup archive is written to
+        target      string    // namespace the backup is restored into
+        overwrite   bool      // replace entities that already exist when restoring
+        from        string    // endpoint compared from, as PROFILE_OR_HOST[/NAMESPACE]
+        to          string    // endpoint compared to, as PROFILE_OR_HOST[/NAMESPACE]
+        format      string    // output format of a diff (text or json)
+        graphFormat string    // output format of a graph (dot, json or mermaid)
+    }
+
+    // promote
+    promote struct {
+        from      string    // endpoint the entities are copied from, as PROFILE_OR_HOST[/NAMESPACE]
+        to        string    // endpoint the entities are copied to, as PROFILE_OR_HOST[/NAMESPACE]
+        dryRun    bool      // list the changes without making them
     }
 
     // rule
@@ -106,12 +169,20 @@ var flags struct {
 
     // api
     api struct {
-        action     string
-        path       string
-        verb       string
-        basepath   string
-        apiname    string
-        configfile string
+        action        string
+        path          string
+        verb          string
+        basepath      string
+        apiname       string
+        configfile    string
+        format        string
+        out           string
+        cors          bool
+        rateLimit     string
+        requireApiKey bool
+        data          string
+        headers       []string
+        expectStatus  int
     }
 }
 
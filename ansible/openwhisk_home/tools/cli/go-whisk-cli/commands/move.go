@@ -0,0 +1,401 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+    "errors"
+    "fmt"
+
+    "../../go-whisk/whisk"
+    "../wski18n"
+
+    "github.com/fatih/color"
+    "github.com/mattn/go-colorable"
+    "github.com/spf13/cobra"
+)
+
+var actionMoveCmd = &cobra.Command{
+    Use:   "move OLD_ACTION_NAME NEW_ACTION_NAME",
+    Short: wski18n.T("rename an action or move it to another package, updating the rules, sequences, and APIs that refer to it"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        return moveEntityCmd("action", "Action move", args)
+    },
+}
+
+var triggerMoveCmd = &cobra.Command{
+    Use:   "move OLD_TRIGGER_NAME NEW_TRIGGER_NAME",
+    Short: wski18n.T("rename a trigger, updating the rules that refer to it"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        return moveEntityCmd("trigger", "Trigger move", args)
+    },
+}
+
+func moveEntityCmd(kind string, commandName string, args []string) error {
+    if whiskErr := checkArgs(args, 2, 2, commandName,
+            wski18n.T("An existing name and a new name are required.")); whiskErr != nil {
+        return whiskErr
+    }
+
+    var qNames []QualifiedName
+    for _, arg := range args {
+        qName, err := parseQualifiedName(arg)
+        if err != nil {
+            whisk.Debug(whisk.DbgError, "parseQualifiedName(%s) failed: %s\n", arg, err)
+            errMsg := wski18n.T("'{{.name}}' is not a valid qualified name: {{.err}}",
+                    map[string]interface{}{"name": arg, "err": err})
+            werr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return werr
+        }
+        qNames = append(qNames, qName)
+    }
+
+    // Use promote to copy entities to another namespace
+    if qNames[0].namespace != qNames[1].namespace {
+        whisk.Debug(whisk.DbgError, "Namespaces '%s' and '%s' differ\n", qNames[0].namespace, qNames[1].namespace)
+        errMsg := wski18n.T("The {{.kind}} can only be moved within its namespace.", map[string]interface{}{"kind": kind})
+        whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+            whisk.DISPLAY_USAGE)
+        return whiskErr
+    }
+
+    client.Namespace = qNames[0].namespace
+    backup, err := backupNamespace(qNames[0].namespace)
+    if err != nil {
+        return err
+    }
+
+    updated, err := moveEntity(backup, kind, qNames[0].entityName, qNames[1].entityName)
+    if err != nil {
+        return err
+    }
+
+    fmt.Fprintf(color.Output,
+        wski18n.T("{{.ok}} moved {{.kind}} {{.old}} to {{.new}}; {{.count}} references updated\n",
+            map[string]interface{}{
+                "ok": color.GreenString("ok:"),
+                "kind": kind,
+                "old": boldString(qNames[0].entityName),
+                "new": boldString(qNames[1].entityName),
+                "count": updated}))
+    return nil
+}
+
+/*
+Move an action or trigger of a backed up namespace to a new name.  The entity is copied to the new name, the
+rules, sequences, and APIs that refer to it are updated, and the original is deleted.  Each step records how to
+undo it, so that a failed move leaves the namespace as it was.  Returns the number of entities updated.
+*/
+func moveEntity(backup *NamespaceBackup, kind string, oldName string, newName string) (int, error) {
+    var undo []func() error
+    var updated int
+
+    namespace := backup.Namespace
+    newRef := "/" + namespace + "/" + newName
+    isOld := func(ref string) bool {
+        return getGraphEntityName(ref, namespace) == oldName
+    }
+    fail := func(err error) (int, error) {
+        return 0, makeMoveError(kind, oldName, newName, err, undo)
+    }
+
+    switch kind {
+    case "action":
+        var action *whisk.Action
+        for _, backupAction := range backup.Actions {
+            if getBackupActionName(backupAction) == oldName {
+                action = backupAction
+            }
+        }
+        if action == nil {
+            return 0, makeMoveNotFoundError(kind, oldName)
+        }
+
+        moved := &whisk.Action{
+            Name: newName,
+            Exec: action.Exec,
+            Annotations: action.Annotations,
+            Parameters: action.Parameters,
+            Limits: action.Limits,
+            Publish: action.Publish,
+        }
+        if _, _, err := client.Actions.Insert(moved, false); err != nil {
+            whisk.Debug(whisk.DbgError, "client.Actions.Insert(%#v, false) failed: %s\n", moved, err)
+            return fail(err)
+        }
+        undo = append(undo, func() error {
+            _, err := client.Actions.Delete(newName)
+            return err
+        })
+    case "trigger":
+        var trigger *whisk.Trigger
+        for _, backupTrigger := range backup.Triggers {
+            if backupTrigger.Name == oldName {
+                trigger = backupTrigger
+            }
+        }
+        if trigger == nil {
+            return 0, makeMoveNotFoundError(kind, oldName)
+        }
+
+        // Feed parameters live with the feed provider rather than the trigger, so the feed cannot be moved
+        if feed := getValueString(trigger.Annotations, "feed"); len(feed) > 0 {
+            whisk.Debug(whisk.DbgError, "Trigger '%s' has feed '%s'\n", oldName, feed)
+            errMsg := wski18n.T("The trigger {{.name}} receives events from feed {{.feed}} and cannot be moved; create a new trigger with the feed instead.",
+                    map[string]interface{}{"name": oldName, "feed": feed})
+            return 0, whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.NO_DISPLAY_USAGE)
+        }
+
+        moved := &whisk.Trigger{
+            Name: newName,
+            Annotations: trigger.Annotations,
+            Parameters: trigger.Parameters,
+            Limits: trigger.Limits,
+            Publish: trigger.Publish,
+        }
+        if _, _, err := client.Triggers.Insert(moved, false); err != nil {
+            whisk.Debug(whisk.DbgError, "client.Triggers.Insert(%#v, false) failed: %s\n", moved, err)
+            return fail(err)
+        }
+        undo = append(undo, func() error {
+            _, _, err := client.Triggers.Delete(newName)
+            return err
+        })
+    }
+
+    for _, rule := range backup.Rules {
+        rule := rule
+        ruleTrigger := getRuleEntityName(rule.Trigger, namespace)
+        ruleAction := getRuleEntityName(rule.Action, namespace)
+
+        if kind == "trigger" && isOld(ruleTrigger) {
+            ruleTrigger = newRef
+        } else if kind == "action" && isOld(ruleAction) {
+            ruleAction = newRef
+        } else {
+            continue
+        }
+
+        if err := updateMoveRule(rule.Name, ruleTrigger, ruleAction, rule.Status); err != nil {
+            return fail(err)
+        }
+        updated++
+        undo = append(undo, func() error {
+            return updateMoveRule(rule.Name, getRuleEntityName(rule.Trigger, namespace),
+                getRuleEntityName(rule.Action, namespace), rule.Status)
+        })
+    }
+
+    if kind != "action" {
+        return updated, deleteMovedEntity(kind, oldName, newName, undo)
+    }
+
+    for _, action := range backup.Actions {
+        if action.Exec == nil || len(action.Exec.Components) == 0 {
+            continue
+        }
+
+        var components []string
+        changed := false
+        for _, component := range action.Exec.Components {
+            if isOld(component) {
+                component = newRef
+                changed = true
+            }
+            components = append(components, component)
+        }
+        if !changed {
+            continue
+        }
+
+        sequence := &whisk.Action{
+            Name: getBackupActionName(action),
+            Exec: &whisk.Exec{Kind: action.Exec.Kind, Components: components},
+            Annotations: action.Annotations,
+            Parameters: action.Parameters,
+            Limits: action.Limits,
+            Publish: action.Publish,
+        }
+        if _, _, err := client.Actions.Insert(sequence, true); err != nil {
+            whisk.Debug(whisk.DbgError, "client.Actions.Insert(%#v, true) failed: %s\n", sequence, err)
+            return fail(err)
+        }
+        updated++
+
+        original := *sequence
+        original.Exec = action.Exec
+        undo = append(undo, func() error {
+            _, _, err := client.Actions.Insert(&original, true)
+            return err
+        })
+    }
+
+    for _, retApi := range backup.Apis {
+        swagger := retApi.Swagger
+        original, err := marshalApiSwagger(swagger)
+        if err != nil {
+            return fail(err)
+        }
+
+        changed := false
+        for _, operations := range swagger.Paths {
+            for _, operation := range operations {
+                ext := operation["x-ibm-op-ext"]
+                if ext == nil {
+                    continue
+                }
+                name, _ := ext["actionName"].(string)
+                actionNamespace, _ := ext["actionNamespace"].(string)
+                if isOld(getQualifiedName(name, actionNamespace)) {
+                    setApiOperationAction(operation, QualifiedName{namespace: namespace, entityName: newName})
+                    changed = true
+                }
+            }
+        }
+        if !changed {
+            continue
+        }
+
+        if err = insertMoveApi(swagger, namespace, ""); err != nil {
+            return fail(err)
+        }
+        updated++
+        undo = append(undo, func() error {
+            return insertMoveApi(nil, namespace, original)
+        })
+    }
+
+    return updated, deleteMovedEntity(kind, oldName, newName, undo)
+}
+
+// deleteMovedEntity deletes the original entity, the last step of a move
+func deleteMovedEntity(kind string, oldName string, newName string, undo []func() error) error {
+    var err error
+
+    switch kind {
+    case "action":
+        _, err = client.Actions.Delete(oldName)
+    case "trigger":
+        _, _, err = client.Triggers.Delete(oldName)
+    }
+
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "Delete of %s '%s' failed: %s\n", kind, oldName, err)
+        return makeMoveError(kind, oldName, newName, err, undo)
+    }
+
+    return nil
+}
+
+// updateMoveRule replaces a rule with one that has the given trigger and action; an updated rule is active
+func updateMoveRule(name string, trigger string, action string, status string) error {
+    rule := &whisk.Rule{
+        Name: name,
+        Trigger: trigger,
+        Action: action,
+    }
+
+    if _, _, err := client.Rules.Insert(rule, true); err != nil {
+        whisk.Debug(whisk.DbgError, "client.Rules.Insert(%#v, true) failed: %s\n", rule, err)
+        return err
+    }
+
+    if status == "inactive" {
+        if _, _, err := client.Rules.SetState(name, "inactive"); err != nil {
+            whisk.Debug(whisk.DbgError, "client.Rules.SetState(%s, inactive) failed: %s\n", name, err)
+            return err
+        }
+    }
+
+    return nil
+}
+
+// insertMoveApi replaces an API with the given swagger, or with the swagger already serialized as JSON
+func insertMoveApi(swagger *whisk.ApiSwagger, namespace string, swaggerJSON string) error {
+    var err error
+
+    api := new(whisk.Api)
+    api.Namespace = namespace
+    api.Swagger = swaggerJSON
+    if swagger != nil {
+        if api.Swagger, err = marshalApiSwagger(swagger); err != nil {
+            return err
+        }
+    }
+
+    sendApi := new(whisk.SendApi)
+    sendApi.ApiDoc = api
+
+    if _, _, err = client.Apis.Insert(sendApi, true); err != nil {
+        whisk.Debug(whisk.DbgError, "client.Apis.Insert(%#v, true) failed: %s\n", sendApi, err)
+    }
+
+    return err
+}
+
+// rollbackMove undoes the completed steps of a move, latest first, and reports whether they were all undone
+func rollbackMove(undo []func() error) bool {
+    rolledBack := true
+
+    for i := len(undo) - 1; i >= 0; i-- {
+        if err := undo[i](); err != nil {
+            whisk.Debug(whisk.DbgError, "Rollback step %d failed: %s\n", i, err)
+            fmt.Fprintf(colorable.NewColorableStderr(),
+                wski18n.T("{{.warning}} unable to roll back a step of the move: {{.err}}\n",
+                    map[string]interface{}{"warning": color.YellowString("warning:"), "err": err}))
+            rolledBack = false
+        }
+    }
+
+    return rolledBack
+}
+
+// makeMoveError rolls back the completed steps of a failed move and describes the failure
+func makeMoveError(kind string, oldName string, newName string, err error, undo []func() error) error {
+    params := map[string]interface{}{"kind": kind, "old": oldName, "new": newName, "err": err}
+
+    errMsg := wski18n.T("Unable to move {{.kind}} '{{.old}}' to '{{.new}}': {{.err}}", params)
+    if len(undo) > 0 {
+        if rollbackMove(undo) {
+            errMsg = wski18n.T("Unable to move {{.kind}} '{{.old}}' to '{{.new}}': {{.err}}; the changes were rolled back", params)
+        } else {
+            errMsg = wski18n.T("Unable to move {{.kind}} '{{.old}}' to '{{.new}}': {{.err}}; some changes could not be rolled back", params)
+        }
+    }
+
+    return whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+        whisk.NO_DISPLAY_USAGE)
+}
+
+func makeMoveNotFoundError(kind string, name string) error {
+    whisk.Debug(whisk.DbgError, "%s '%s' not found\n", kind, name)
+    errMsg := wski18n.T("The {{.kind}} '{{.name}}' does not exist.", map[string]interface{}{"kind": kind, "name": name})
+    return whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+        whisk.NO_DISPLAY_USAGE)
+}
+
+func init() {
+    actionCmd.AddCommand(actionMoveCmd)
+    triggerCmd.AddCommand(triggerMoveCmd)
+}
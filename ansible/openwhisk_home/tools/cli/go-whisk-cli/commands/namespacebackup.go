@@ -0,0 +1,758 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+    "archive/tar"
+    "compress/gzip"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "os"
+    "path"
+    "strings"
+    "time"
+
+    "github.com/fatih/color"
+    "github.com/mattn/go-colorable"
+    "github.com/spf13/cobra"
+
+    "../../go-whisk/whisk"
+    "../wski18n"
+)
+
+// Version of the backup archive layout; restore refuses archives written by a newer layout
+const NamespaceBackupVersion = 1
+
+const NamespaceBackupHeader = "backup.json"
+
+// Number of APIs requested per page when backing up APIs
+const ApiBackupPageSize = 50
+
+// NamespaceBackup is the content of a backup archive.  The archive holds backup.json followed by one JSON
+// file per entity, named after the entity kind and its name; for example actions/mypackage/hello.json.
+type NamespaceBackup struct {
+    Version     int                 `json:"version"`
+    Namespace   string              `json:"namespace"`
+    Created     int64               `json:"created"`
+    Packages    []*whisk.Package    `json:"-"`
+    Actions     []*whisk.Action     `json:"-"`
+    Triggers    []*whisk.Trigger    `json:"-"`
+    Rules       []*whisk.Rule       `json:"-"`
+    Apis        []*whisk.RetApi     `json:"-"`
+}
+
+var namespaceBackupCmd = &cobra.Command{
+    Use:   "backup [NAMESPACE] --out FILE",
+    Short: wski18n.T("back up the actions, packages, triggers, rules, and APIs of a namespace to an archive"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        var qName QualifiedName
+        var err error
+
+        if whiskErr := checkArgs(args, 0, 1, "Namespace backup",
+                wski18n.T("An optional namespace is the only valid argument.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        if len(flags.namespace.out) == 0 {
+            whisk.Debug(whisk.DbgError, "No backup file specified\n")
+            errMsg := wski18n.T("A backup file is required; specify it with --out.")
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        if len(args) == 1 {
+            qName, err = parseQualifiedName(args[0])
+            if err != nil {
+                whisk.Debug(whisk.DbgError, "parseQualifiedName(%s) failed: %s\n", args[0], err)
+                errMsg := wski18n.T("'{{.name}}' is not a valid qualified name: {{.err}}",
+                        map[string]interface{}{"name": args[0], "err": err})
+                werr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                    whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+                return werr
+            }
+        }
+
+        backup, err := backupNamespace(qName.namespace)
+        if err != nil {
+            return err
+        }
+
+        if err = writeNamespaceBackup(flags.namespace.out, backup); err != nil {
+            return err
+        }
+
+        fmt.Fprintf(color.Output,
+            wski18n.T("{{.ok}} backed up namespace {{.namespace}} to {{.name}}: {{.packages}} packages, {{.actions}} actions, {{.triggers}} triggers, {{.rules}} rules, {{.apis}} APIs\n",
+                map[string]interface{}{
+                    "ok": color.GreenString("ok:"),
+                    "namespace": boldString(backup.Namespace),
+                    "name": flags.namespace.out,
+                    "packages": len(backup.Packages),
+                    "actions": len(backup.Actions),
+                    "triggers": len(backup.Triggers),
+                    "rules": len(backup.Rules),
+                    "apis": len(backup.Apis)}))
+        return nil
+    },
+}
+
+var namespaceRestoreCmd = &cobra.Command{
+    Use:   "restore FILE [--namespace NAMESPACE] [--overwrite]",
+    Short: wski18n.T("restore the entities in a namespace backup archive"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if whiskErr := checkArgs(args, 1, 1, "Namespace restore",
+                wski18n.T("A backup file is required.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        backup, err := readNamespaceBackup(args[0])
+        if err != nil {
+            return err
+        }
+
+        target := flags.namespace.target
+        if len(target) == 0 {
+            target = client.Config.Namespace
+        }
+        target = strings.Trim(target, "/")
+
+        restored, skipped, err := restoreNamespace(backup, target, flags.namespace.overwrite, false)
+        if err != nil {
+            return err
+        }
+
+        fmt.Fprintf(color.Output,
+            wski18n.T("{{.ok}} restored {{.count}} entities from {{.name}} to namespace {{.namespace}}; {{.skipped}} existing entities skipped\n",
+                map[string]interface{}{
+                    "ok": color.GreenString("ok:"),
+                    "count": restored,
+                    "name": args[0],
+                    "namespace": boldString(target),
+                    "skipped": skipped}))
+        return nil
+    },
+}
+
+// backupNamespace reads every entity of the namespace, including the code of each action and the swagger of
+// each API
+func backupNamespace(namespace string) (*NamespaceBackup, error) {
+    ns, _, err := client.Namespaces.Get(namespace)
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "client.Namespaces.Get(%s) error: %s\n", namespace, err)
+        errStr := wski18n.T("Unable to obtain the list of entities for namespace '{{.namespace}}': {{.err}}",
+                map[string]interface{}{"namespace": getClientNamespace(), "err": err})
+        werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_NETWORK,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return nil, werr
+    }
+
+    backup := &NamespaceBackup{
+        Version: NamespaceBackupVersion,
+        Namespace: ns.Name,
+        Created: time.Now().UnixNano() / int64(time.Millisecond),
+    }
+
+    for _, summary := range ns.Contents.Packages {
+        pkg, _, err := client.Packages.Get(summary.Name)
+        if err != nil {
+            return nil, makeBackupError("package", summary.Name, err)
+        }
+        backup.Packages = append(backup.Packages, pkg)
+
+        // The actions of a binding belong to the bound package
+        if pkg.Binding != nil && len(pkg.Binding.Name) > 0 {
+            continue
+        }
+        for _, actionSummary := range pkg.Actions {
+            action, _, err := client.Actions.Get(pkg.Name + "/" + actionSummary.Name)
+            if err != nil {
+                return nil, makeBackupError("action", pkg.Name + "/" + actionSummary.Name, err)
+            }
+            backup.Actions = append(backup.Actions, action)
+        }
+    }
+
+    for _, summary := range ns.Contents.Actions {
+        action, _, err := client.Actions.Get(summary.Name)
+        if err != nil {
+            return nil, makeBackupError("action", summary.Name, err)
+        }
+        backup.Actions = append(backup.Actions, action)
+    }
+
+    for _, summary := range ns.Contents.Triggers {
+        trigger, _, err := client.Triggers.Get(summary.Name)
+        if err != nil {
+            return nil, makeBackupError("trigger", summary.Name, err)
+        }
+        backup.Triggers = append(backup.Triggers, trigger)
+    }
+
+    for _, summary := range ns.Contents.Rules {
+        rule, _, err := client.Rules.Get(summary.Name)
+        if err != nil {
+            return nil, makeBackupError("rule", summary.Name, err)
+        }
+        backup.Rules = append(backup.Rules, rule)
+    }
+
+    // The API gateway is optional, so a namespace without one is backed up without APIs
+    if backup.Apis, err = getAllApis(); err != nil {
+        fmt.Fprintf(colorable.NewColorableStderr(),
+            wski18n.T("{{.warning}} APIs were not backed up: {{.err}}\n",
+                map[string]interface{}{"warning": color.YellowString("warning:"), "err": err}))
+    }
+
+    // The default namespace "_" is resolved from the entities so that fully qualified references to the
+    // namespace can be rewritten on restore
+    if backup.Namespace == "_" || len(backup.Namespace) == 0 {
+        backup.Namespace = getBackupNamespaceName(backup)
+    }
+
+    return backup, nil
+}
+
+// getAllApis lists every API of the namespace with its swagger, a page at a time
+func getAllApis() ([]*whisk.RetApi, error) {
+    var apis []*whisk.RetApi
+
+    options := &whisk.ApiListOptions{Limit: ApiBackupPageSize}
+    for {
+        retApiArray, _, err := client.Apis.List(options)
+        if err != nil {
+            whisk.Debug(whisk.DbgWarn, "client.Apis.List(%#v) error: %s\n", options, err)
+            return apis, err
+        }
+        for _, item := range retApiArray.Apis {
+            if item.ApiValue != nil && item.ApiValue.Swagger != nil {
+                apis = append(apis, item.ApiValue)
+            }
+        }
+        if len(retApiArray.Apis) < options.Limit {
+            return apis, nil
+        }
+        options.Skip += options.Limit
+    }
+}
+
+func getBackupNamespaceName(backup *NamespaceBackup) string {
+    var namespaces []string
+    for _, pkg := range backup.Packages {
+        namespaces = append(namespaces, pkg.Namespace)
+    }
+    for _, action := range backup.Actions {
+        namespaces = append(namespaces, action.Namespace)
+    }
+    for _, trigger := range backup.Triggers {
+        namespaces = append(namespaces, trigger.Namespace)
+    }
+    for _, rule := range backup.Rules {
+        namespaces = append(namespaces, rule.Namespace)
+    }
+
+    for _, namespace := range namespaces {
+        if namespace = strings.SplitN(namespace, "/", 2)[0]; len(namespace) > 0 {
+            return namespace
+        }
+    }
+    return "_"
+}
+
+func makeBackupError(kind string, name string, err error) error {
+    whisk.Debug(whisk.DbgError, "Get of %s '%s' failed: %s\n", kind, name, err)
+    errStr := wski18n.T("Unable to back up {{.kind}} '{{.name}}': {{.err}}",
+            map[string]interface{}{"kind": kind, "name": name, "err": err})
+    return whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_NETWORK,
+        whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+}
+
+// getBackupActionName returns the action name relative to its namespace, including its package
+func getBackupActionName(action *whisk.Action) string {
+    if i := strings.Index(action.Namespace, "/"); i >= 0 {
+        return action.Namespace[i+1:] + "/" + action.Name
+    }
+    return action.Name
+}
+
+func writeNamespaceBackup(filename string, backup *NamespaceBackup) error {
+    file, err := os.Create(filename)
+    if err != nil {
+        return makeBackupFileError(filename, err)
+    }
+    defer file.Close()
+
+    gzipWriter := gzip.NewWriter(file)
+    tarWriter := tar.NewWriter(gzipWriter)
+
+    add := func(name string, value interface{}) error {
+        content, err := json.MarshalIndent(value, "", "    ")
+        if err != nil {
+            return err
+        }
+
+        header := &tar.Header{
+            Name: name,
+            Mode: 0644,
+            Size: int64(len(content)),
+            ModTime: time.Unix(0, backup.Created * int64(time.Millisecond)),
+        }
+        if err = tarWriter.WriteHeader(header); err != nil {
+            return err
+        }
+        _, err = tarWriter.Write(content)
+        return err
+    }
+
+    err = add(NamespaceBackupHeader, backup)
+    for i := 0; err == nil && i < len(backup.Packages); i++ {
+        err = add(path.Join("packages", backup.Packages[i].Name + ".json"), backup.Packages[i])
+    }
+    for i := 0; err == nil && i < len(backup.Actions); i++ {
+        err = add(path.Join("actions", getBackupActionName(backup.Actions[i]) + ".json"), backup.Actions[i])
+    }
+    for i := 0; err == nil && i < len(backup.Triggers); i++ {
+        err = add(path.Join("triggers", backup.Triggers[i].Name + ".json"), backup.Triggers[i])
+    }
+    for i := 0; err == nil && i < len(backup.Rules); i++ {
+        err = add(path.Join("rules", backup.Rules[i].Name + ".json"), backup.Rules[i])
+    }
+    for i := 0; err == nil && i < len(backup.Apis); i++ {
+        err = add(path.Join("apis", fmt.Sprintf("%d.json", i)), backup.Apis[i])
+    }
+    if err == nil {
+        err = tarWriter.Close()
+    }
+    if err == nil {
+        err = gzipWriter.Close()
+    }
+    if err != nil {
+        return makeBackupFileError(filename, err)
+    }
+
+    return nil
+}
+
+func readNamespaceBackup(filename string) (*NamespaceBackup, error) {
+    var backup *NamespaceBackup
+
+    file, err := os.Open(filename)
+    if err != nil {
+        return nil, makeBackupFileError(filename, err)
+    }
+    defer file.Close()
+
+    gzipReader, err := gzip.NewReader(file)
+    if err != nil {
+        return nil, makeBackupFileError(filename, err)
+    }
+    tarReader := tar.NewReader(gzipReader)
+
+    for {
+        header, err := tarReader.Next()
+        if err == io.EOF {
+            break
+        } else if err != nil {
+            return nil, makeBackupFileError(filename, err)
+        }
+
+        content, err := ioutil.ReadAll(tarReader)
+        if err != nil {
+            return nil, makeBackupFileError(filename, err)
+        }
+
+        if header.Name == NamespaceBackupHeader {
+            backup = new(NamespaceBackup)
+            err = json.Unmarshal(content, backup)
+            if err == nil && backup.Version > NamespaceBackupVersion {
+                whisk.Debug(whisk.DbgError, "Backup version %d is newer than %d\n", backup.Version, NamespaceBackupVersion)
+                errStr := wski18n.T("Backup '{{.name}}' has version {{.version}}; this CLI restores backups up to version {{.supported}}",
+                        map[string]interface{}{"name": filename, "version": backup.Version, "supported": NamespaceBackupVersion})
+                return nil, whisk.MakeWskError(errors.New(errStr), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                    whisk.NO_DISPLAY_USAGE)
+            }
+        } else if backup == nil {
+            err = errors.New(wski18n.T("{{.header}} must be the first file in the archive",
+                map[string]interface{}{"header": NamespaceBackupHeader}))
+        } else {
+            switch strings.SplitN(header.Name, "/", 2)[0] {
+            case "packages":
+                pkg := new(whisk.Package)
+                err = json.Unmarshal(content, pkg)
+                backup.Packages = append(backup.Packages, pkg)
+            case "actions":
+                action := new(whisk.Action)
+                err = json.Unmarshal(content, action)
+                backup.Actions = append(backup.Actions, action)
+            case "triggers":
+                trigger := new(whisk.Trigger)
+                err = json.Unmarshal(content, trigger)
+                backup.Triggers = append(backup.Triggers, trigger)
+            case "rules":
+                rule := new(whisk.Rule)
+                err = json.Unmarshal(content, rule)
+                backup.Rules = append(backup.Rules, rule)
+            case "apis":
+                api := new(whisk.RetApi)
+                err = json.Unmarshal(content, api)
+                backup.Apis = append(backup.Apis, api)
+            default:
+                whisk.Debug(whisk.DbgWarn, "Ignoring unknown backup file '%s'\n", header.Name)
+            }
+        }
+        if err != nil {
+            return nil, makeBackupFileError(filename, err)
+        }
+    }
+
+    if backup == nil {
+        return nil, makeBackupFileError(filename, errors.New(wski18n.T("{{.header}} must be the first file in the archive",
+            map[string]interface{}{"header": NamespaceBackupHeader})))
+    }
+
+    return backup, nil
+}
+
+func makeBackupFileError(filename string, err error) error {
+    whisk.Debug(whisk.DbgError, "Backup file '%s' error: %s\n", filename, err)
+    errStr := wski18n.T("Unable to use backup file '{{.name}}': {{.err}}",
+            map[string]interface{}{"name": filename, "err": err})
+    return whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL,
+        whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+}
+
+// restoreNamespace recreates the backed up entities in target in dependency order: packages, bindings,
+// actions, sequences, triggers, rules, and APIs.  References to the backed up namespace are rewritten to
+// target.  Entities that already exist are skipped unless overwrite is set.  When configureFeeds is set, the
+// feed of each new trigger is created again with the feed parameters given by --param.
+func restoreNamespace(backup *NamespaceBackup, target string, overwrite bool, configureFeeds bool) (int, int, error) {
+    var restored, skipped int
+
+    client.Namespace = target
+    rename := func(name string) string {
+        return renameBackupReference(name, backup.Namespace, target)
+    }
+
+    // Report an insert; a conflict is a skipped entity rather than a failure when not overwriting
+    skip := func(kind string, name string) {
+        skipped++
+        fmt.Fprintf(colorable.NewColorableStderr(),
+            wski18n.T("{{.warning}} {{.kind}} {{.name}} already exists; use --overwrite to replace it\n",
+                map[string]interface{}{"warning": color.YellowString("warning:"), "kind": kind, "name": name}))
+    }
+    result := func(kind string, name string, resp *http.Response, err error) error {
+        if err == nil {
+            restored++
+            return nil
+        }
+        if !overwrite && resp != nil && resp.StatusCode == http.StatusConflict {
+            skip(kind, name)
+            return nil
+        }
+
+        whisk.Debug(whisk.DbgError, "Restore of %s '%s' failed: %s\n", kind, name, err)
+        errStr := wski18n.T("Unable to restore {{.kind}} '{{.name}}': {{.err}}",
+                map[string]interface{}{"kind": kind, "name": name, "err": err})
+        return whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_NETWORK,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    }
+
+    // Packages come before bindings since a binding may refer to a package in the backup
+    for _, bindings := range []bool{false, true} {
+        for _, pkg := range backup.Packages {
+            isBinding := pkg.Binding != nil && len(pkg.Binding.Name) > 0
+            if isBinding != bindings {
+                continue
+            }
+
+            restore := &whisk.Package{
+                Name: pkg.Name,
+                Publish: pkg.Publish,
+                Annotations: pkg.Annotations,
+                Parameters: pkg.Parameters,
+            }
+            if isBinding {
+                restore.Binding = &whisk.Binding{
+                    Namespace: strings.Trim(rename("/" + pkg.Binding.Namespace), "/"),
+                    Name: pkg.Binding.Name,
+                }
+            }
+
+            _, resp, err := client.Packages.Insert(restore, overwrite)
+            if err = result("package", pkg.Name, resp, err); err != nil {
+                return restored, skipped, err
+            }
+        }
+    }
+
+    for _, action := range sortBackupActions(backup.Actions) {
+        restore := &whisk.Action{
+            Name: getBackupActionName(action),
+            Exec: action.Exec,
+            Annotations: action.Annotations,
+            Parameters: action.Parameters,
+            Limits: action.Limits,
+            Publish: action.Publish,
+        }
+        if restore.Exec != nil {
+            for i, component := range restore.Exec.Components {
+                restore.Exec.Components[i] = rename(component)
+            }
+        }
+
+        _, resp, err := client.Actions.Insert(restore, overwrite)
+        if err = result("action", restore.Name, resp, err); err != nil {
+            return restored, skipped, err
+        }
+    }
+
+    for _, trigger := range backup.Triggers {
+        restore := &whisk.Trigger{
+            Name: trigger.Name,
+            Annotations: trigger.Annotations,
+            Parameters: trigger.Parameters,
+            Limits: trigger.Limits,
+            Publish: trigger.Publish,
+        }
+
+        // An existing trigger keeps the feed it is already configured with
+        feed := getValueString(trigger.Annotations, "feed")
+        _, _, getErr := client.Triggers.Get(trigger.Name)
+        exists := getErr == nil
+
+        _, resp, err := client.Triggers.Insert(restore, overwrite)
+        inserted := err == nil
+        if err = result("trigger", trigger.Name, resp, err); err != nil {
+            return restored, skipped, err
+        }
+
+        if len(feed) == 0 || !inserted || exists {
+            continue
+        }
+
+        // Feed parameters live with the feed provider rather than the trigger, so they cannot be restored
+        if configureFeeds {
+            if err = configureBackupFeed(trigger.Name, rename("/" + strings.TrimPrefix(feed, "/")), target); err != nil {
+                return restored, skipped, err
+            }
+        } else {
+            fmt.Fprintf(colorable.NewColorableStderr(),
+                wski18n.T("{{.warning}} trigger {{.name}} uses feed {{.feed}}; configure the feed again to receive events\n",
+                    map[string]interface{}{"warning": color.YellowString("warning:"), "name": trigger.Name, "feed": feed}))
+        }
+    }
+
+    for _, rule := range backup.Rules {
+        restore := &whisk.Rule{
+            Name: rule.Name,
+            Trigger: rename(getRuleEntityName(rule.Trigger, backup.Namespace)),
+            Action: rename(getRuleEntityName(rule.Action, backup.Namespace)),
+            Publish: rule.Publish,
+        }
+
+        _, resp, err := client.Rules.Insert(restore, overwrite)
+        inserted := err == nil
+        if err = result("rule", rule.Name, resp, err); err != nil {
+            return restored, skipped, err
+        }
+
+        // Rules are created active
+        if rule.Status == "inactive" && inserted {
+            if _, _, err = client.Rules.SetState(rule.Name, "inactive"); err != nil {
+                whisk.Debug(whisk.DbgError, "client.Rules.SetState(%s, inactive) failed: %s\n", rule.Name, err)
+                errStr := wski18n.T("Unable to disable rule '{{.name}}': {{.err}}",
+                        map[string]interface{}{"name": rule.Name, "err": err})
+                return restored, skipped, whisk.MakeWskErrorFromWskError(errors.New(errStr), err,
+                    whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            }
+        }
+    }
+
+    // The gateway replaces an API with the same base path on every insert, so existing APIs are looked up
+    // first to skip them unless overwrite is set
+    existingApis := make(map[string]bool)
+    if len(backup.Apis) > 0 && !overwrite {
+        apis, err := getAllApis()
+        if err != nil {
+            return restored, skipped, result("API", backup.Apis[0].Swagger.BasePath, nil, err)
+        }
+        for _, api := range apis {
+            existingApis[api.Swagger.BasePath] = true
+        }
+    }
+
+    for _, retApi := range backup.Apis {
+        swagger := retApi.Swagger
+        if existingApis[swagger.BasePath] {
+            skip("API", swagger.BasePath)
+            continue
+        }
+
+        for _, operations := range swagger.Paths {
+            for _, operation := range operations {
+                renameApiOperationNamespace(operation, backup.Namespace, target)
+            }
+        }
+
+        var err error
+
+        api := new(whisk.Api)
+        api.Namespace = target
+        if api.Swagger, err = marshalApiSwagger(swagger); err != nil {
+            return restored, skipped, result("API", swagger.BasePath, nil, err)
+        }
+
+        sendApi := new(whisk.SendApi)
+        sendApi.ApiDoc = api
+
+        _, resp, err := client.Apis.Insert(sendApi, overwrite)
+        if err = result("API", swagger.BasePath, resp, err); err != nil {
+            return restored, skipped, err
+        }
+    }
+
+    return restored, skipped, nil
+}
+
+// configureBackupFeed runs the CREATE lifecycle event of a feed for a restored trigger, deleting the trigger when
+// the feed fails
+func configureBackupFeed(triggerName string, feed string, target string) error {
+    feedParams := flags.common.param
+    defer func() {
+        flags.common.param = feedParams
+        client.Namespace = target
+    }()
+
+    flags.common.param = append([]string{}, feedParams...)
+    flags.common.param = append(flags.common.param, getFormattedJSON("lifecycleEvent", "CREATE"))
+    flags.common.param = append(flags.common.param, getFormattedJSON("triggerName", getQualifiedName(triggerName, target)))
+    flags.common.param = append(flags.common.param, getFormattedJSON("authKey", client.Config.AuthToken))
+
+    err := configureFeed(triggerName, feed)
+    if err != nil {
+        client.Namespace = target
+        if _, _, delErr := client.Triggers.Delete(triggerName); delErr != nil {
+            whisk.Debug(whisk.DbgError, "client.Triggers.Delete(%s) failed: %s\n", triggerName, delErr)
+        }
+    }
+
+    return err
+}
+
+// sortBackupActions orders actions so that every sequence follows the actions it is composed of
+func sortBackupActions(actions []*whisk.Action) []*whisk.Action {
+    var sorted []*whisk.Action
+    added := make(map[*whisk.Action]bool)
+    byName := make(map[string]*whisk.Action)
+
+    for _, action := range actions {
+        byName[getBackupActionName(action)] = action
+    }
+
+    var add func(action *whisk.Action, depth int)
+    add = func(action *whisk.Action, depth int) {
+        // The depth check stops on cyclic sequences, which the controller rejects anyway
+        if added[action] || depth > len(actions) {
+            return
+        }
+        if action.Exec != nil {
+            for _, component := range action.Exec.Components {
+                // Components are fully qualified: /namespace/[package/]action
+                parts := strings.SplitN(strings.TrimPrefix(component, "/"), "/", 2)
+                if len(parts) == 2 && byName[parts[1]] != nil {
+                    add(byName[parts[1]], depth + 1)
+                }
+            }
+        }
+        if !added[action] {
+            added[action] = true
+            sorted = append(sorted, action)
+        }
+    }
+
+    for _, action := range actions {
+        add(action, 0)
+    }
+
+    return sorted
+}
+
+// renameBackupReference moves a fully qualified name in the source namespace to the target namespace
+func renameBackupReference(name string, source string, target string) string {
+    for _, namespace := range []string{source, "_"} {
+        if strings.HasPrefix(name, "/" + namespace + "/") {
+            return "/" + target + strings.TrimPrefix(name, "/" + namespace)
+        }
+        if name == "/" + namespace {
+            return "/" + target
+        }
+    }
+    return name
+}
+
+// getRuleEntityName returns the fully qualified trigger or action name of a rule, which the controller returns
+// either as a string or as a namespace and name object
+func getRuleEntityName(value interface{}, namespace string) string {
+    switch value := value.(type) {
+    case string:
+        return getQualifiedName(value, namespace)
+    case map[string]interface{}:
+        entityNamespace, _ := value["namespace"].(string)
+        name, _ := value["name"].(string)
+        return getQualifiedName(name, entityNamespace)
+    }
+    return ""
+}
+
+func renameApiOperationNamespace(operation map[string]map[string]interface{}, source string, target string) {
+    ext := operation["x-ibm-op-ext"]
+    if ext == nil {
+        return
+    }
+
+    if namespace, ok := ext["actionNamespace"].(string); ok && (namespace == source || namespace == "_") {
+        ext["actionNamespace"] = target
+    }
+    if backendUrl, ok := ext["backendUrl"].(string); ok {
+        for _, namespace := range []string{source, "_"} {
+            backendUrl = strings.Replace(backendUrl, "/namespaces/" + namespace + "/", "/namespaces/" + target + "/", 1)
+        }
+        ext["backendUrl"] = backendUrl
+    }
+}
+
+func init() {
+    namespaceBackupCmd.Flags().StringVarP(&flags.namespace.out, "out", "o", "", wski18n.T("write the backup archive to `FILE`"))
+
+    namespaceRestoreCmd.Flags().StringVar(&flags.namespace.target, "namespace", "", wski18n.T("restore into `NAMESPACE` instead of the current namespace"))
+    namespaceRestoreCmd.Flags().BoolVar(&flags.namespace.overwrite, "overwrite", false, wski18n.T("replace entities that already exist"))
+
+    namespaceCmd.AddCommand(
+        namespaceBackupCmd,
+        namespaceRestoreCmd,
+    )
+}
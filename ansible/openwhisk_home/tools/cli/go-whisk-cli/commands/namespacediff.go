@@ -0,0 +1,370 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+    "crypto/sha256"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "sort"
+
+    "../../go-whisk/whisk"
+    "../wski18n"
+
+    "github.com/fatih/color"
+    "github.com/spf13/cobra"
+)
+
+// Entity kinds in the order they are reported
+var diffEntityKinds = []string{"package", "action", "trigger", "rule", "api"}
+
+const (
+    DiffAdded   = "added"
+    DiffRemoved = "removed"
+    DiffChanged = "changed"
+)
+
+// NamespaceDiff lists the entities that differ between two namespaces.  Added entities exist only in the "to"
+// namespace and removed entities exist only in the "from" namespace.
+type NamespaceDiff struct {
+    From        string          `json:"from"`
+    To          string          `json:"to"`
+    Added       int             `json:"added"`
+    Removed     int             `json:"removed"`
+    Changed     int             `json:"changed"`
+    Entities    []*EntityDiff   `json:"entities"`
+}
+
+type EntityDiff struct {
+    Kind        string          `json:"kind"`
+    Name        string          `json:"name"`
+    Change      string          `json:"change"`
+    Fields      []*FieldDiff    `json:"fields,omitempty"`
+}
+
+type FieldDiff struct {
+    Field       string          `json:"field"`
+    From        interface{}     `json:"from"`
+    To          interface{}     `json:"to"`
+}
+
+var namespaceDiffCmd = &cobra.Command{
+    Use:   "diff --from PROFILE_OR_HOST[/NAMESPACE] --to PROFILE_OR_HOST[/NAMESPACE]",
+    Short: wski18n.T("compare the actions, packages, triggers, rules, and APIs of two namespaces"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if whiskErr := checkArgs(args, 0, 0, "Namespace diff",
+                wski18n.T("No arguments are required.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        if flags.namespace.format != "text" && flags.namespace.format != "json" {
+            whisk.Debug(whisk.DbgError, "Invalid diff format '%s'\n", flags.namespace.format)
+            errMsg := wski18n.T("Invalid format '{{.format}}'; the format must be text or json.",
+                    map[string]interface{}{"format": flags.namespace.format})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        from, err := getEndpoint(flags.namespace.from)
+        if err != nil {
+            return err
+        }
+
+        to, err := getEndpoint(flags.namespace.to)
+        if err != nil {
+            return err
+        }
+
+        fromBackup, err := getEndpointBackup(from)
+        if err != nil {
+            return err
+        }
+
+        toBackup, err := getEndpointBackup(to)
+        if err != nil {
+            return err
+        }
+
+        diff := diffNamespaces(fromBackup, toBackup)
+        diff.From = flags.namespace.from
+        diff.To = flags.namespace.to
+
+        if flags.namespace.format == "json" {
+            printJsonNoColor(diff)
+        } else {
+            printNamespaceDiff(diff)
+        }
+
+        return nil
+    },
+}
+
+// getEndpointBackup reads every entity of the namespace of an endpoint
+func getEndpointBackup(endpoint *Endpoint) (*NamespaceBackup, error) {
+    var backup *NamespaceBackup
+
+    err := withEndpoint(endpoint, func() error {
+        var err error
+        backup, err = backupNamespace(endpoint.Namespace)
+        return err
+    })
+
+    return backup, err
+}
+
+// diffNamespaces compares the entities of two namespaces field by field.  References to entities of the same
+// namespace are compared relative to it, so that a namespace matches a copy of itself under another name.
+func diffNamespaces(from *NamespaceBackup, to *NamespaceBackup) *NamespaceDiff {
+    diff := &NamespaceDiff{Entities: []*EntityDiff{}}
+    fromEntities := getDiffEntities(from)
+    toEntities := getDiffEntities(to)
+
+    for _, kind := range diffEntityKinds {
+        names := make(map[string]bool)
+        for name := range fromEntities[kind] {
+            names[name] = true
+        }
+        for name := range toEntities[kind] {
+            names[name] = true
+        }
+
+        var sortedNames []string
+        for name := range names {
+            sortedNames = append(sortedNames, name)
+        }
+        sort.Strings(sortedNames)
+
+        for _, name := range sortedNames {
+            fromFields, inFrom := fromEntities[kind][name]
+            toFields, inTo := toEntities[kind][name]
+            entity := &EntityDiff{Kind: kind, Name: name}
+
+            if !inFrom {
+                entity.Change = DiffAdded
+                diff.Added++
+            } else if !inTo {
+                entity.Change = DiffRemoved
+                diff.Removed++
+            } else if entity.Fields = diffEntityFields(fromFields, toFields); len(entity.Fields) > 0 {
+                entity.Change = DiffChanged
+                diff.Changed++
+            } else {
+                continue
+            }
+
+            diff.Entities = append(diff.Entities, entity)
+        }
+    }
+
+    return diff
+}
+
+func diffEntityFields(from map[string]interface{}, to map[string]interface{}) []*FieldDiff {
+    var fieldDiffs []*FieldDiff
+
+    fields := make(map[string]bool)
+    for field := range from {
+        fields[field] = true
+    }
+    for field := range to {
+        fields[field] = true
+    }
+
+    var sortedFields []string
+    for field := range fields {
+        sortedFields = append(sortedFields, field)
+    }
+    sort.Strings(sortedFields)
+
+    for _, field := range sortedFields {
+        // Values are compared as JSON so that numbers decoded differently still match
+        fromJSON, _ := json.Marshal(from[field])
+        toJSON, _ := json.Marshal(to[field])
+        if string(fromJSON) != string(toJSON) {
+            fieldDiffs = append(fieldDiffs, &FieldDiff{Field: field, From: from[field], To: to[field]})
+        }
+    }
+
+    return fieldDiffs
+}
+
+// getDiffEntities flattens the entities of a namespace into compared fields, keyed by entity kind and name
+func getDiffEntities(backup *NamespaceBackup) map[string]map[string]map[string]interface{} {
+    namespace := backup.Namespace
+    entities := make(map[string]map[string]map[string]interface{})
+    for _, kind := range diffEntityKinds {
+        entities[kind] = make(map[string]map[string]interface{})
+    }
+
+    for _, pkg := range backup.Packages {
+        fields := make(map[string]interface{})
+        if pkg.Binding != nil && len(pkg.Binding.Name) > 0 {
+            fields["binding"] = renameBackupReference(getQualifiedName(pkg.Binding.Name, pkg.Binding.Namespace),
+                namespace, "_")
+        }
+        if pkg.Publish != nil {
+            fields["publish"] = *pkg.Publish
+        }
+        addKeyValueDiffFields(fields, "parameters", pkg.Parameters)
+        addKeyValueDiffFields(fields, "annotations", pkg.Annotations)
+        entities["package"][pkg.Name] = fields
+    }
+
+    for _, action := range backup.Actions {
+        fields := make(map[string]interface{})
+        if action.Exec != nil {
+            fields["kind"] = action.Exec.Kind
+            if action.Exec.Code != nil {
+                fields["code"] = getCodeHash(*action.Exec.Code)
+            }
+            if len(action.Exec.Jar) > 0 {
+                fields["code"] = getCodeHash(action.Exec.Jar)
+            }
+            if len(action.Exec.Image) > 0 {
+                fields["image"] = action.Exec.Image
+            }
+            if len(action.Exec.Main) > 0 {
+                fields["main"] = action.Exec.Main
+            }
+            if len(action.Exec.Components) > 0 {
+                var components []string
+                for _, component := range action.Exec.Components {
+                    components = append(components, renameBackupReference(component, namespace, "_"))
+                }
+                fields["components"] = components
+            }
+        }
+        addLimitsDiffFields(fields, action.Limits)
+        addKeyValueDiffFields(fields, "parameters", action.Parameters)
+        addKeyValueDiffFields(fields, "annotations", action.Annotations)
+        entities["action"][getBackupActionName(action)] = fields
+    }
+
+    for _, trigger := range backup.Triggers {
+        fields := make(map[string]interface{})
+        addKeyValueDiffFields(fields, "parameters", trigger.Parameters)
+        addKeyValueDiffFields(fields, "annotations", trigger.Annotations)
+        entities["trigger"][trigger.Name] = fields
+    }
+
+    for _, rule := range backup.Rules {
+        entities["rule"][rule.Name] = map[string]interface{}{
+            "trigger": renameBackupReference(getRuleEntityName(rule.Trigger, namespace), namespace, "_"),
+            "action": renameBackupReference(getRuleEntityName(rule.Action, namespace), namespace, "_"),
+            "status": rule.Status,
+        }
+    }
+
+    for _, api := range backup.Apis {
+        swagger := api.Swagger
+        fields := map[string]interface{}{
+            "cors": swagger.CorsEnabled(),
+            "apikey": swagger.ApiKeyRequired(),
+        }
+        for relpath, operations := range swagger.Paths {
+            for verb, operation := range operations {
+                field := "paths." + relpath + "." + verb
+                if ext := operation["x-ibm-op-ext"]; ext != nil {
+                    name, _ := ext["actionName"].(string)
+                    actionNamespace, _ := ext["actionNamespace"].(string)
+                    fields[field] = renameBackupReference(getQualifiedName(name, actionNamespace), namespace, "_")
+                }
+                if limit := swagger.GetRateLimit(relpath, verb); limit != nil {
+                    fields[field + ".ratelimit"] = fmt.Sprintf("%d/%s", limit.Rate, limit.Unit)
+                }
+            }
+        }
+        entities["api"][swagger.BasePath] = fields
+    }
+
+    return entities
+}
+
+func addKeyValueDiffFields(fields map[string]interface{}, prefix string, keyValues whisk.KeyValueArr) {
+    for _, keyValue := range keyValues {
+        fields[prefix + "." + keyValue.Key] = keyValue.Value
+    }
+}
+
+func addLimitsDiffFields(fields map[string]interface{}, limits *whisk.Limits) {
+    if limits == nil {
+        return
+    }
+    if limits.Timeout != nil {
+        fields["limits.timeout"] = *limits.Timeout
+    }
+    if limits.Memory != nil {
+        fields["limits.memory"] = *limits.Memory
+    }
+    if limits.Logsize != nil {
+        fields["limits.logs"] = *limits.Logsize
+    }
+}
+
+func getCodeHash(code string) string {
+    return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(code)))
+}
+
+func printNamespaceDiff(diff *NamespaceDiff) {
+    for _, entity := range diff.Entities {
+        switch entity.Change {
+        case DiffAdded:
+            fmt.Fprintf(color.Output, "%s %s %s\n", color.GreenString("+"), entity.Kind, boldString(entity.Name))
+        case DiffRemoved:
+            fmt.Fprintf(color.Output, "%s %s %s\n", color.RedString("-"), entity.Kind, boldString(entity.Name))
+        case DiffChanged:
+            fmt.Fprintf(color.Output, "%s %s %s\n", color.YellowString("~"), entity.Kind, boldString(entity.Name))
+            for _, field := range entity.Fields {
+                fmt.Fprintf(color.Output, "    %s: %s => %s\n", field.Field, getDiffValueString(field.From),
+                    getDiffValueString(field.To))
+            }
+        }
+    }
+
+    if len(diff.Entities) == 0 {
+        fmt.Fprintf(color.Output, wski18n.T("{{.ok}} no differences\n",
+            map[string]interface{}{"ok": color.GreenString("ok:")}))
+    } else {
+        fmt.Fprintf(color.Output, wski18n.T("{{.added}} added, {{.removed}} removed, {{.changed}} changed\n",
+            map[string]interface{}{"added": diff.Added, "removed": diff.Removed, "changed": diff.Changed}))
+    }
+}
+
+func getDiffValueString(value interface{}) string {
+    if value == nil {
+        return wski18n.T("(none)")
+    }
+
+    valueJSON, err := json.Marshal(value)
+    if err != nil {
+        return fmt.Sprintf("%v", value)
+    }
+    return string(valueJSON)
+}
+
+func init() {
+    namespaceDiffCmd.Flags().StringVar(&flags.namespace.from, "from", "", wski18n.T("compare from the namespace of `PROFILE_OR_HOST[/NAMESPACE]`"))
+    namespaceDiffCmd.Flags().StringVar(&flags.namespace.to, "to", "", wski18n.T("compare to the namespace of `PROFILE_OR_HOST[/NAMESPACE]`"))
+    namespaceDiffCmd.Flags().StringVar(&flags.namespace.format, "format", "text", wski18n.T("output `FORMAT`: text or json"))
+
+    namespaceCmd.AddCommand(namespaceDiffCmd)
+}
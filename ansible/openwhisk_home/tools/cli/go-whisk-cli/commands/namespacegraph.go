@@ -0,0 +1,256 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+
+    "../../go-whisk/whisk"
+    "../wski18n"
+
+    "github.com/fatih/color"
+    "github.com/spf13/cobra"
+)
+
+// Shapes of the graph nodes of each entity kind in the dot format
+var graphDotShapes = map[string]string{
+    "package": "folder",
+    "action": "box",
+    "trigger": "diamond",
+    "rule": "ellipse",
+    "api": "hexagon",
+}
+
+// NamespaceGraph holds the entities of a namespace and the references between them.  Entities of the namespace
+// are named relative to it; entities of other namespaces are fully qualified.
+type NamespaceGraph struct {
+    Namespace   string          `json:"namespace"`
+    Nodes       []*GraphNode    `json:"nodes"`
+    Edges       []*GraphEdge    `json:"edges"`
+}
+
+type GraphNode struct {
+    Id          string          `json:"id"`
+    Kind        string          `json:"kind"`
+    Name        string          `json:"name"`
+}
+
+// GraphEdge is a reference from one entity to another: a trigger fires a rule, a rule invokes an action, a
+// sequence includes an action, a binding binds a package, and an API calls an action
+type GraphEdge struct {
+    From        string          `json:"from"`
+    To          string          `json:"to"`
+    Relation    string          `json:"relation"`
+    Label       string          `json:"label,omitempty"`
+}
+
+var namespaceGraphCmd = &cobra.Command{
+    Use:   "graph [NAMESPACE] [--format dot|json|mermaid]",
+    Short: wski18n.T("draw the references between the entities of a namespace"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        var qName QualifiedName
+        var err error
+
+        if whiskErr := checkArgs(args, 0, 1, "Namespace graph",
+                wski18n.T("An optional namespace is the only valid argument.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        format := flags.namespace.graphFormat
+        if format != "dot" && format != "json" && format != "mermaid" {
+            whisk.Debug(whisk.DbgError, "Invalid graph format '%s'\n", format)
+            errMsg := wski18n.T("Invalid format '{{.format}}'; the format must be dot, json, or mermaid.",
+                    map[string]interface{}{"format": format})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        if len(args) == 1 {
+            qName, err = parseQualifiedName(args[0])
+            if err != nil {
+                whisk.Debug(whisk.DbgError, "parseQualifiedName(%s) failed: %s\n", args[0], err)
+                errMsg := wski18n.T("'{{.name}}' is not a valid qualified name: {{.err}}",
+                        map[string]interface{}{"name": args[0], "err": err})
+                werr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                    whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+                return werr
+            }
+        }
+
+        backup, err := backupNamespace(qName.namespace)
+        if err != nil {
+            return err
+        }
+
+        graph := getNamespaceGraph(backup)
+
+        switch format {
+        case "json":
+            printJsonNoColor(graph)
+        case "mermaid":
+            fmt.Fprint(color.Output, getGraphMermaid(graph))
+        default:
+            fmt.Fprint(color.Output, getGraphDot(graph))
+        }
+
+        return nil
+    },
+}
+
+// getNamespaceGraph builds the graph of a namespace from its rules, sequences, bindings, and APIs
+func getNamespaceGraph(backup *NamespaceBackup) *NamespaceGraph {
+    graph := &NamespaceGraph{Namespace: backup.Namespace, Nodes: []*GraphNode{}, Edges: []*GraphEdge{}}
+    nodes := make(map[string]*GraphNode)
+
+    // Nodes are added for the entities of the namespace as well as for the entities they refer to
+    node := func(kind string, name string) string {
+        name = getGraphEntityName(name, backup.Namespace)
+        id := kind + ":" + name
+        if nodes[id] == nil {
+            nodes[id] = &GraphNode{Id: id, Kind: kind, Name: name}
+            graph.Nodes = append(graph.Nodes, nodes[id])
+        }
+        return id
+    }
+    edge := func(from string, to string, relation string, label string) {
+        graph.Edges = append(graph.Edges, &GraphEdge{From: from, To: to, Relation: relation, Label: label})
+    }
+
+    for _, pkg := range backup.Packages {
+        id := node("package", pkg.Name)
+        if pkg.Binding != nil && len(pkg.Binding.Name) > 0 {
+            edge(id, node("package", getQualifiedName(pkg.Binding.Name, pkg.Binding.Namespace)), "binds", "")
+        }
+    }
+
+    for _, action := range backup.Actions {
+        id := node("action", getBackupActionName(action))
+        if action.Exec != nil {
+            for i, component := range action.Exec.Components {
+                edge(id, node("action", component), "includes", fmt.Sprintf("%d", i + 1))
+            }
+        }
+    }
+
+    for _, trigger := range backup.Triggers {
+        node("trigger", trigger.Name)
+    }
+
+    for _, rule := range backup.Rules {
+        id := node("rule", rule.Name)
+        edge(node("trigger", getRuleEntityName(rule.Trigger, backup.Namespace)), id, "fires", "")
+        edge(id, node("action", getRuleEntityName(rule.Action, backup.Namespace)), "invokes", "")
+    }
+
+    for _, api := range backup.Apis {
+        swagger := api.Swagger
+        id := node("api", swagger.BasePath)
+
+        var relpaths []string
+        for relpath := range swagger.Paths {
+            relpaths = append(relpaths, relpath)
+        }
+        sort.Strings(relpaths)
+
+        for _, relpath := range relpaths {
+            var verbs []string
+            for verb := range swagger.Paths[relpath] {
+                verbs = append(verbs, verb)
+            }
+            sort.Strings(verbs)
+
+            for _, verb := range verbs {
+                ext := swagger.Paths[relpath][verb]["x-ibm-op-ext"]
+                if ext == nil {
+                    continue
+                }
+                name, _ := ext["actionName"].(string)
+                namespace, _ := ext["actionNamespace"].(string)
+                edge(id, node("action", getQualifiedName(name, namespace)), "calls",
+                    strings.ToUpper(verb) + " " + relpath)
+            }
+        }
+    }
+
+    return graph
+}
+
+// getGraphEntityName names an entity of the namespace relative to it and any other entity by its fully
+// qualified name
+func getGraphEntityName(name string, namespace string) string {
+    name = renameBackupReference(name, namespace, "_")
+    return strings.TrimPrefix(name, "/_/")
+}
+
+func getGraphDot(graph *NamespaceGraph) string {
+    var lines []string
+
+    lines = append(lines, fmt.Sprintf("digraph %q {", graph.Namespace))
+    lines = append(lines, "    rankdir=LR;")
+    for _, node := range graph.Nodes {
+        lines = append(lines, fmt.Sprintf("    %q [label=%q, shape=%s];", node.Id, node.Kind + "\n" + node.Name,
+            graphDotShapes[node.Kind]))
+    }
+    for _, edge := range graph.Edges {
+        label := edge.Relation
+        if len(edge.Label) > 0 {
+            label += " " + edge.Label
+        }
+        lines = append(lines, fmt.Sprintf("    %q -> %q [label=%q];", edge.From, edge.To, label))
+    }
+    lines = append(lines, "}")
+
+    return strings.Join(lines, "\n") + "\n"
+}
+
+func getGraphMermaid(graph *NamespaceGraph) string {
+    var lines []string
+
+    // Mermaid node ids cannot contain the slashes and colons of entity names
+    ids := make(map[string]string)
+    lines = append(lines, "graph LR")
+    for i, node := range graph.Nodes {
+        ids[node.Id] = fmt.Sprintf("n%d", i)
+        lines = append(lines, fmt.Sprintf("    %s[\"%s %s\"]", ids[node.Id], node.Kind, getMermaidText(node.Name)))
+    }
+    for _, edge := range graph.Edges {
+        label := edge.Relation
+        if len(edge.Label) > 0 {
+            label += " " + edge.Label
+        }
+        lines = append(lines, fmt.Sprintf("    %s -->|\"%s\"| %s", ids[edge.From], getMermaidText(label), ids[edge.To]))
+    }
+
+    return strings.Join(lines, "\n") + "\n"
+}
+
+func getMermaidText(text string) string {
+    return strings.Replace(text, "\"", "#quot;", -1)
+}
+
+func init() {
+    namespaceGraphCmd.Flags().StringVar(&flags.namespace.graphFormat, "format", "dot", wski18n.T("output `FORMAT`: dot, json, or mermaid"))
+
+    namespaceCmd.AddCommand(namespaceGraphCmd)
+}
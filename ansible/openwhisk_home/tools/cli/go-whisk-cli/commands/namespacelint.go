@@ -0,0 +1,298 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+
+    "../../go-whisk/whisk"
+    "../wski18n"
+
+    "github.com/fatih/color"
+    "github.com/spf13/cobra"
+)
+
+const (
+    LintError   = "error"
+    LintWarning = "warning"
+    LintInfo    = "info"
+)
+
+// Severities from the most to the least severe, the order lint problems are listed in
+var lintSeverities = []string{LintError, LintWarning, LintInfo}
+
+type LintProblem struct {
+    Severity    string
+    Kind        string
+    Name        string
+    Message     string
+}
+
+var namespaceLintCmd = &cobra.Command{
+    Use:   "lint [NAMESPACE]",
+    Short: wski18n.T("check the references between the entities of a namespace"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        var qName QualifiedName
+        var err error
+
+        if whiskErr := checkArgs(args, 0, 1, "Namespace lint",
+                wski18n.T("An optional namespace is the only valid argument.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        if len(args) == 1 {
+            qName, err = parseQualifiedName(args[0])
+            if err != nil {
+                whisk.Debug(whisk.DbgError, "parseQualifiedName(%s) failed: %s\n", args[0], err)
+                errMsg := wski18n.T("'{{.name}}' is not a valid qualified name: {{.err}}",
+                        map[string]interface{}{"name": args[0], "err": err})
+                werr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                    whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+                return werr
+            }
+        }
+
+        backup, err := backupNamespace(qName.namespace)
+        if err != nil {
+            return err
+        }
+
+        problems := lintNamespace(backup)
+        counts := make(map[string]int)
+        for _, problem := range problems {
+            counts[problem.Severity]++
+            printLintProblem(problem)
+        }
+
+        if counts[LintError] > 0 {
+            whisk.Debug(whisk.DbgError, "Lint of namespace '%s' found %d errors\n", backup.Namespace, counts[LintError])
+            errMsg := wski18n.T("{{.errors}} errors and {{.warnings}} warnings found in namespace {{.namespace}}",
+                    map[string]interface{}{
+                        "errors": counts[LintError],
+                        "warnings": counts[LintWarning],
+                        "namespace": backup.Namespace})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.NO_DISPLAY_USAGE)
+            return whiskErr
+        }
+
+        fmt.Fprintf(color.Output,
+            wski18n.T("{{.ok}} no errors found in namespace {{.namespace}}; {{.warnings}} warnings\n",
+                map[string]interface{}{
+                    "ok": color.GreenString("ok:"),
+                    "namespace": boldString(backup.Namespace),
+                    "warnings": counts[LintWarning]}))
+        return nil
+    },
+}
+
+/*
+Check the integrity of a namespace.  Every reference in the namespace graph must lead to an existing entity;
+references to other namespaces are checked through the SDK.  An action of a binding is looked up in the package
+the binding is bound to, since the backup does not list the actions of bindings.  Triggers that fire no rules are
+reported as well, with a warning for feed triggers since their feed keeps running.
+*/
+func lintNamespace(backup *NamespaceBackup) []*LintProblem {
+    var problems []*LintProblem
+
+    graph := getNamespaceGraph(backup)
+    nodes := make(map[string]*GraphNode)
+    for _, node := range graph.Nodes {
+        nodes[node.Id] = node
+    }
+
+    exists := make(map[string]bool)
+    for _, pkg := range backup.Packages {
+        exists["package:" + pkg.Name] = true
+    }
+    for _, action := range backup.Actions {
+        exists["action:" + getBackupActionName(action)] = true
+    }
+    for _, trigger := range backup.Triggers {
+        exists["trigger:" + trigger.Name] = true
+    }
+
+    // Bound packages are named relative to the namespace when they belong to it
+    bound := make(map[string]string)
+    for _, pkg := range backup.Packages {
+        if pkg.Binding != nil && len(pkg.Binding.Name) > 0 {
+            bound[pkg.Name] = getGraphEntityName(getQualifiedName(pkg.Binding.Name, pkg.Binding.Namespace),
+                backup.Namespace)
+        }
+    }
+
+    reported := make(map[string]bool)
+    fired := make(map[string]bool)
+
+    for _, edge := range graph.Edges {
+        entity, ref := nodes[edge.From], nodes[edge.To]
+        if edge.Relation == "fires" {
+            entity, ref = ref, entity
+            fired[ref.Name] = true
+        }
+
+        name := ref.Name
+        if parts := strings.SplitN(name, "/", 2); ref.Kind == "action" && len(parts) == 2 && len(bound[parts[0]]) > 0 {
+            name = bound[parts[0]] + "/" + parts[1]
+            if _, checked := exists[ref.Id]; !checked && name[0] != '/' {
+                exists[ref.Id] = exists["action:" + name]
+            }
+        }
+
+        // Only entities of other namespaces are fully qualified
+        if _, checked := exists[ref.Id]; !checked && len(name) > 0 && name[0] == '/' {
+            found, err := lintEntityExists(ref.Kind, name)
+            if err != nil {
+                problem := &LintProblem{
+                    Severity: LintWarning,
+                    Kind: entity.Kind,
+                    Name: entity.Name,
+                    Message: wski18n.T("unable to check {{.kind}} {{.name}}: {{.err}}",
+                        map[string]interface{}{"kind": ref.Kind, "name": name, "err": err}),
+                }
+                problems = append(problems, problem)
+            }
+            exists[ref.Id] = found || err != nil
+        }
+
+        if exists[ref.Id] || reported[edge.From + " " + edge.To] {
+            continue
+        }
+        reported[edge.From + " " + edge.To] = true
+
+        problem := &LintProblem{Severity: LintError, Kind: entity.Kind, Name: entity.Name}
+        params := map[string]interface{}{"ref": ref.Name}
+        switch edge.Relation {
+        case "binds":
+            problem.Message = wski18n.T("bound to package {{.ref}}, which does not exist", params)
+        case "includes":
+            problem.Message = wski18n.T("sequence includes action {{.ref}}, which does not exist", params)
+        case "invokes":
+            problem.Message = wski18n.T("invokes action {{.ref}}, which does not exist", params)
+        case "fires":
+            problem.Message = wski18n.T("fired by trigger {{.ref}}, which does not exist", params)
+        case "calls":
+            problem.Message = wski18n.T("{{.operation}} calls action {{.ref}}, which does not exist",
+                map[string]interface{}{"operation": edge.Label, "ref": ref.Name})
+        }
+        problems = append(problems, problem)
+    }
+
+    for _, trigger := range backup.Triggers {
+        if fired[trigger.Name] {
+            continue
+        }
+        if feed := getValueString(trigger.Annotations, "feed"); len(feed) > 0 {
+            problems = append(problems, &LintProblem{
+                Severity: LintWarning,
+                Kind: "trigger",
+                Name: trigger.Name,
+                Message: wski18n.T("receives events from feed {{.feed}} but fires no rules",
+                    map[string]interface{}{"feed": feed}),
+            })
+        } else {
+            problems = append(problems, &LintProblem{
+                Severity: LintInfo,
+                Kind: "trigger",
+                Name: trigger.Name,
+                Message: wski18n.T("fires no rules"),
+            })
+        }
+    }
+
+    for _, rule := range backup.Rules {
+        if rule.Status == "inactive" {
+            problems = append(problems, &LintProblem{
+                Severity: LintInfo,
+                Kind: "rule",
+                Name: rule.Name,
+                Message: wski18n.T("is disabled"),
+            })
+        }
+    }
+
+    var sorted []*LintProblem
+    for _, severity := range lintSeverities {
+        for _, problem := range problems {
+            if problem.Severity == severity {
+                sorted = append(sorted, problem)
+            }
+        }
+    }
+
+    return sorted
+}
+
+// lintEntityExists gets a fully qualified entity of another namespace; an entity that is not found is not an error
+func lintEntityExists(kind string, name string) (bool, error) {
+    var resp *http.Response
+    var err error
+
+    qName, err := parseQualifiedName(name)
+    if err != nil {
+        return false, err
+    }
+
+    savedNamespace := client.Namespace
+    client.Namespace = qName.namespace
+    defer func() {
+        client.Namespace = savedNamespace
+    }()
+
+    switch kind {
+    case "package":
+        _, resp, err = client.Packages.Get(qName.entityName)
+    case "action":
+        _, resp, err = client.Actions.Get(qName.entityName)
+    case "trigger":
+        _, resp, err = client.Triggers.Get(qName.entityName)
+    default:
+        return true, nil
+    }
+
+    if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+        whisk.Debug(whisk.DbgInfo, "%s '%s' does not exist\n", kind, name)
+        return false, nil
+    }
+
+    return err == nil, err
+}
+
+func printLintProblem(problem *LintProblem) {
+    var severity string
+
+    switch problem.Severity {
+    case LintError:
+        severity = color.RedString(wski18n.T("error:"))
+    case LintWarning:
+        severity = color.YellowString(wski18n.T("warning:"))
+    default:
+        severity = color.CyanString(wski18n.T("info:"))
+    }
+
+    fmt.Fprintf(color.Output, "%s %s %s %s\n", severity, problem.Kind, boldString(problem.Name), problem.Message)
+}
+
+func init() {
+    namespaceCmd.AddCommand(namespaceLintCmd)
+}
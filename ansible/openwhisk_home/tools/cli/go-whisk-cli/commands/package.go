@@ -189,6 +189,10 @@ var packageCreateCmd = &cobra.Command{
       p.Publish = &shared
     }
 
+    if err = checkPackagePolicy(p, false); err != nil {
+      return err
+    }
+
     p, _, err = client.Packages.Insert(p, false)
     if err != nil {
       whisk.Debug(whisk.DbgError, "client.Packages.Insert(%#v, false) failed: %s\n", p, err)
@@ -265,7 +269,19 @@ var packageUpdateCmd = &cobra.Command{
       p.Publish = &shared
     }
 
-    p, _, err = client.Packages.Insert(p, true)
+    if err = mergeUpdateKeyValues("package", p.Name, &p.Parameters, &p.Annotations); err != nil {
+      return err
+    }
+
+    if err = checkPackagePolicy(p, true); err != nil {
+      return err
+    }
+
+    if len(flags.common.ifVersion) > 0 {
+      p, _, err = client.Packages.InsertIfVersion(p, flags.common.ifVersion)
+    } else {
+      p, _, err = client.Packages.Insert(p, true)
+    }
     if err != nil {
       whisk.Debug(whisk.DbgError, "client.Packages.Insert(%#v, true) failed: %s\n", p, err)
       errStr := wski18n.T("Package update failed: {{.err}}", map[string]interface{}{"err":err})
@@ -367,21 +383,63 @@ var packageDeleteCmd = &cobra.Command{
     }
     client.Namespace = qName.namespace
 
-    _, err = client.Packages.Delete(qName.entityName)
-    if err != nil {
-      whisk.Debug(whisk.DbgError, "client.Packages.Delete(%s) failed: %s\n", qName.entityName, err)
-      errStr := wski18n.T("Package delete failed: {{.err}}", map[string]interface{}{"err":err})
-      werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
-      return werr
+    if isNamePattern(qName.entityName) {
+      var related func(names []string) ([]string, error)
+      if flags.pkg.recursive {
+        related = func(names []string) ([]string, error) {
+          var items []string
+          for _, name := range names {
+            contents, err := getPackageContents(name)
+            if err != nil {
+              return nil, err
+            }
+            for _, content := range contents {
+              items = append(items, "action " + content)
+            }
+          }
+          return items, nil
+        }
+      }
+      return deleteEntityPattern("package", qName.entityName, related,
+        func(name string) error {
+          return deletePackage(QualifiedName{namespace: qName.namespace, entityName: name})
+        },
+        getPackageDeletedMessage)
     }
 
-    fmt.Fprintf(color.Output,
-      wski18n.T("{{.ok}} deleted package {{.name}}\n",
-        map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(qName.entityName)}))
+    if err = deletePackage(qName); err != nil {
+      return err
+    }
+
+    fmt.Fprintf(color.Output, getPackageDeletedMessage(qName.entityName))
     return nil
   },
 }
 
+// deletePackage deletes a package, first deleting its actions and feeds when --recursive is given
+func deletePackage(qName QualifiedName) error {
+  if flags.pkg.recursive {
+    if err := deletePackageContents(qName); err != nil {
+      return err
+    }
+  }
+
+  _, err := client.Packages.Delete(qName.entityName)
+  if err != nil {
+    whisk.Debug(whisk.DbgError, "client.Packages.Delete(%s) failed: %s\n", qName.entityName, err)
+    errStr := wski18n.T("Package delete failed: {{.err}}", map[string]interface{}{"err":err})
+    werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    return werr
+  }
+
+  return nil
+}
+
+func getPackageDeletedMessage(packageName string) string {
+  return wski18n.T("{{.ok}} deleted package {{.name}}\n",
+    map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(packageName)})
+}
+
 var packageListCmd = &cobra.Command{
   Use:           "list [NAMESPACE]",
   Short:         wski18n.T("list all packages"),
@@ -530,6 +588,64 @@ var packageRefreshCmd = &cobra.Command{
   },
 }
 
+// deletePackageContents deletes the actions and feeds of a package, once the deletion is confirmed
+func deletePackageContents(qName QualifiedName) error {
+  names, err := getPackageContents(qName.entityName)
+  if err != nil {
+    return err
+  }
+
+  items := []string{"package " + qName.entityName}
+  for _, name := range names {
+    items = append(items, "action " + name)
+  }
+  if err = confirmDelete(items); err != nil {
+    return err
+  }
+
+  for _, name := range names {
+    if _, err = client.Actions.Delete(name); err != nil {
+      whisk.Debug(whisk.DbgError, "client.Actions.Delete(%s) error: %s\n", name, err)
+      errStr := wski18n.T("Unable to delete action '{{.name}}': {{.err}}",
+          map[string]interface{}{"name": name, "err": err})
+      werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+      return werr
+    }
+    fmt.Fprintf(color.Output,
+      wski18n.T("{{.ok}} deleted action {{.name}}\n",
+        map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(name)}))
+  }
+
+  return nil
+}
+
+// getPackageContents returns the qualified names of the actions and feeds of a package, or none for a binding
+func getPackageContents(name string) ([]string, error) {
+  var names []string
+
+  pkg, _, err := client.Packages.Get(name)
+  if err != nil {
+    whisk.Debug(whisk.DbgError, "client.Packages.Get(%s) failed: %s\n", name, err)
+    errStr := wski18n.T("Unable to get package '{{.name}}': {{.err}}",
+        map[string]interface{}{"name": name, "err": err})
+    werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    return nil, werr
+  }
+
+  // The actions of a binding belong to the bound package
+  if pkg.Binding == nil || len(pkg.Binding.Name) == 0 {
+    added := make(map[string]bool)
+    for _, action := range append(pkg.Actions, pkg.Feeds...) {
+      if !added[action.Name] {
+        added[action.Name] = true
+        names = append(names, name + "/" + action.Name)
+      }
+    }
+  }
+
+  return names, nil
+}
+
 func init() {
   packageCreateCmd.Flags().StringSliceVarP(&flags.common.annotation, "annotation", "a", []string{}, wski18n.T("annotation values in `KEY VALUE` format"))
   packageCreateCmd.Flags().StringVarP(&flags.common.annotFile, "annotation-file", "A", "", wski18n.T("`FILE` containing annotation values in JSON format"))
@@ -542,9 +658,19 @@ func init() {
   packageUpdateCmd.Flags().StringSliceVarP(&flags.common.param, "param", "p", []string{}, wski18n.T("parameter values in `KEY VALUE` format"))
   packageUpdateCmd.Flags().StringVarP(&flags.common.paramFile, "param-file", "P", "", wski18n.T("`FILE` containing parameter values in JSON format"))
   packageUpdateCmd.Flags().StringVar(&flags.common.shared, "shared", "", wski18n.T("package visibility `SCOPE`; yes = shared, no = private"))
+  packageUpdateCmd.Flags().StringVar(&flags.common.ifVersion, "if-version", "", wski18n.T("update only if the package is still at `VERSION`, as shown by get"))
+  packageUpdateCmd.Flags().BoolVar(&flags.common.paramMerge, "param-merge", false, wski18n.T("merge the parameters into those of the existing package instead of replacing them"))
+  packageUpdateCmd.Flags().StringSliceVar(&flags.common.paramRemove, "param-remove", []string{}, wski18n.T("remove the parameter `KEY` from the existing package"))
+  packageUpdateCmd.Flags().BoolVar(&flags.common.annotMerge, "annotation-merge", false, wski18n.T("merge the annotations into those of the existing package instead of replacing them"))
+  packageUpdateCmd.Flags().StringSliceVar(&flags.common.annotRemove, "annotation-remove", []string{}, wski18n.T("remove the annotation `KEY` from the existing package"))
 
   packageGetCmd.Flags().BoolVarP(&flags.common.summary, "summary", "s", false, wski18n.T("summarize package details"))
 
+  packageDeleteCmd.Flags().BoolVarP(&flags.pkg.recursive, "recursive", "r", false, wski18n.T("also delete the actions and feeds of the package"))
+  packageDeleteCmd.Flags().BoolVarP(&flags.common.yes, "yes", "y", false, wski18n.T("delete without asking for confirmation"))
+  packageDeleteCmd.Flags().BoolVar(&flags.bulk.regex, "regex", false, wski18n.T("treat PACKAGE_NAME as a regular expression matching the packages to delete"))
+  packageDeleteCmd.Flags().IntVarP(&flags.bulk.concurrency, "concurrency", "c", 10, wski18n.T("number of packages deleted at a time when PACKAGE_NAME is a pattern"))
+
   packageBindCmd.Flags().StringSliceVarP(&flags.common.annotation, "annotation", "a", []string{}, wski18n.T("annotation values in `KEY VALUE` format"))
   packageBindCmd.Flags().StringVarP(&flags.common.annotFile, "annotation-file", "A", "", wski18n.T("`FILE` containing annotation values in JSON format"))
   packageBindCmd.Flags().StringSliceVarP(&flags.common.param, "param", "p", []string{}, wski18n.T("parameter values in `KEY VALUE` format"))
@@ -0,0 +1,277 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "path"
+    "regexp"
+    "strings"
+
+    "github.com/fatih/color"
+    "github.com/mattn/go-colorable"
+    "github.com/mitchellh/go-homedir"
+
+    "../../go-whisk/whisk"
+    "../wski18n"
+)
+
+const (
+    PolicyEnforce  = "enforce"
+    PolicyAdvisory = "advisory"
+)
+
+// Parameter values that look like credentials; checked when a policy sets "secrets"
+var defaultSecretPatterns = []string{
+    "AKIA[0-9A-Z]{16}",                                                             // AWS access key ID
+    "-----BEGIN [A-Z ]*PRIVATE KEY-----",                                           // PEM private key
+    "^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}:[0-9A-Za-z]{64}$", // whisk authorization key
+    "xox[abprs]-[0-9A-Za-z-]{10,}",                                                 // Slack token
+    "(sk|rk)_live_[0-9A-Za-z]{16,}",                                                // Stripe secret key
+}
+
+/*
+Policy holds the team rules that created and updated actions, packages, and triggers are checked against.  The
+policy file is JSON or YAML, for example:
+
+    mode: enforce
+    maxMemory: 512
+    maxTimeout: 60000
+    requiredAnnotations: [description]
+    namePrefixes:
+      billing: billing-
+    secrets: true
+    unpublishedPackages: [internal-*]
+
+In enforce mode, the default, a violation fails the command; in advisory mode it is reported as a warning.
+*/
+type Policy struct {
+    Mode                string              `json:"mode,omitempty"`
+    MaxMemory           int                 `json:"maxMemory,omitempty"`            // MB
+    MaxTimeout          int                 `json:"maxTimeout,omitempty"`           // milliseconds
+    RequiredAnnotations []string            `json:"requiredAnnotations,omitempty"`
+    NamePrefixes        map[string]string   `json:"namePrefixes,omitempty"`         // package name pattern to action name prefix
+    Secrets             bool                `json:"secrets,omitempty"`              // reject parameter values that look like credentials
+    SecretPatterns      []string            `json:"secretPatterns,omitempty"`       // additional credential patterns
+    UnpublishedPackages []string            `json:"unpublishedPackages,omitempty"`  // package name patterns that cannot be published
+    File                string              `json:"-"`
+    secretRegexps       []*regexp.Regexp
+}
+
+// getPolicy reads the policy file given by --policy or the POLICY property; there is no policy when neither is set
+func getPolicy() (*Policy, error) {
+    if len(Properties.Policy) == 0 {
+        return nil, nil
+    }
+
+    filename, err := homedir.Expand(Properties.Policy)
+    if err != nil {
+        return nil, makePolicyFileError(Properties.Policy, err)
+    }
+
+    data, err := ioutil.ReadFile(filename)
+    if err != nil {
+        return nil, makePolicyFileError(filename, err)
+    }
+
+    if isYamlFile(filename) {
+        if data, err = yamlToJSON(data); err != nil {
+            return nil, makePolicyFileError(filename, err)
+        }
+    }
+
+    policy := &Policy{File: filename}
+    if err = json.Unmarshal(data, policy); err != nil {
+        return nil, makePolicyFileError(filename, err)
+    }
+
+    if len(policy.Mode) == 0 {
+        policy.Mode = PolicyEnforce
+    }
+    if policy.Mode != PolicyEnforce && policy.Mode != PolicyAdvisory {
+        return nil, makePolicyFileError(filename,
+            errors.New(wski18n.T("the mode must be enforce or advisory")))
+    }
+
+    patterns := policy.SecretPatterns
+    if policy.Secrets {
+        patterns = append(patterns, defaultSecretPatterns...)
+    }
+    for _, pattern := range patterns {
+        secretRegexp, err := regexp.Compile(pattern)
+        if err != nil {
+            return nil, makePolicyFileError(filename, err)
+        }
+        policy.secretRegexps = append(policy.secretRegexps, secretRegexp)
+    }
+
+    return policy, nil
+}
+
+func makePolicyFileError(filename string, err error) error {
+    whisk.Debug(whisk.DbgError, "Policy file '%s' error: %s\n", filename, err)
+    errStr := wski18n.T("Unable to read the policy file '{{.name}}': {{.err}}",
+            map[string]interface{}{"name": filename, "err": err})
+    return whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+        whisk.NO_DISPLAY_USAGE)
+}
+
+// checkActionPolicy checks an action before it is inserted.  An update only checks the fields it changes.
+func checkActionPolicy(action *whisk.Action, update bool) error {
+    policy, err := getPolicy()
+    if err != nil || policy == nil {
+        return err
+    }
+
+    var violations []string
+    if action.Limits != nil && action.Limits.Memory != nil && policy.MaxMemory > 0 &&
+            *action.Limits.Memory > policy.MaxMemory {
+        violations = append(violations, wski18n.T("memory limit {{.memory}} MB exceeds the maximum of {{.max}} MB",
+            map[string]interface{}{"memory": *action.Limits.Memory, "max": policy.MaxMemory}))
+    }
+    if action.Limits != nil && action.Limits.Timeout != nil && policy.MaxTimeout > 0 &&
+            *action.Limits.Timeout > policy.MaxTimeout {
+        violations = append(violations, wski18n.T("timeout {{.timeout}} ms exceeds the maximum of {{.max}} ms",
+            map[string]interface{}{"timeout": *action.Limits.Timeout, "max": policy.MaxTimeout}))
+    }
+
+    // Action names within a package are PACKAGE/ACTION
+    if parts := strings.SplitN(action.Name, "/", 2); len(parts) == 2 {
+        for pattern, prefix := range policy.NamePrefixes {
+            if matched, _ := path.Match(pattern, parts[0]); matched && !strings.HasPrefix(parts[1], prefix) {
+                violations = append(violations,
+                    wski18n.T("actions in package {{.package}} must be named with the prefix '{{.prefix}}'",
+                        map[string]interface{}{"package": parts[0], "prefix": prefix}))
+            }
+        }
+    }
+
+    violations = append(violations, getPolicyAnnotationViolations(policy, action.Annotations, update)...)
+    violations = append(violations, getPolicySecretViolations(policy, action.Parameters)...)
+
+    return enforcePolicy(policy, "action", action.Name, violations)
+}
+
+func checkPackagePolicy(pkg *whisk.Package, update bool) error {
+    policy, err := getPolicy()
+    if err != nil || policy == nil {
+        return err
+    }
+
+    var violations []string
+    if pkg.Publish != nil && *pkg.Publish {
+        for _, pattern := range policy.UnpublishedPackages {
+            if matched, _ := path.Match(pattern, pkg.Name); matched {
+                violations = append(violations, wski18n.T("the package cannot be shared"))
+                break
+            }
+        }
+    }
+
+    violations = append(violations, getPolicyAnnotationViolations(policy, pkg.Annotations, update)...)
+    violations = append(violations, getPolicySecretViolations(policy, pkg.Parameters)...)
+
+    return enforcePolicy(policy, "package", pkg.Name, violations)
+}
+
+func checkTriggerPolicy(trigger *whisk.Trigger, update bool) error {
+    policy, err := getPolicy()
+    if err != nil || policy == nil {
+        return err
+    }
+
+    var violations []string
+    violations = append(violations, getPolicyAnnotationViolations(policy, trigger.Annotations, update)...)
+    violations = append(violations, getPolicySecretViolations(policy, trigger.Parameters)...)
+
+    return enforcePolicy(policy, "trigger", trigger.Name, violations)
+}
+
+// getPolicyAnnotationViolations checks the required annotations.  An update that leaves the annotations unchanged
+// keeps the existing ones, so it is not checked.
+func getPolicyAnnotationViolations(policy *Policy, annotations whisk.KeyValueArr, update bool) []string {
+    var violations []string
+
+    if update && len(annotations) == 0 {
+        return violations
+    }
+
+    for _, key := range policy.RequiredAnnotations {
+        if getValue(annotations, key) == nil {
+            violations = append(violations, wski18n.T("the annotation '{{.key}}' is required",
+                map[string]interface{}{"key": key}))
+        }
+    }
+
+    return violations
+}
+
+func getPolicySecretViolations(policy *Policy, parameters whisk.KeyValueArr) []string {
+    var violations []string
+
+    for _, parameter := range parameters {
+        value, ok := parameter.Value.(string)
+        if !ok {
+            valueJSON, _ := json.Marshal(parameter.Value)
+            value = string(valueJSON)
+        }
+
+        for _, secretRegexp := range policy.secretRegexps {
+            if secretRegexp.MatchString(value) {
+                violations = append(violations, wski18n.T("the value of parameter '{{.key}}' looks like a secret",
+                    map[string]interface{}{"key": parameter.Key}))
+                break
+            }
+        }
+    }
+
+    return violations
+}
+
+// enforcePolicy fails on violations of an enforced policy and warns about violations of an advisory policy
+func enforcePolicy(policy *Policy, kind string, name string, violations []string) error {
+    if len(violations) == 0 {
+        return nil
+    }
+
+    if policy.Mode == PolicyAdvisory {
+        for _, violation := range violations {
+            fmt.Fprintf(colorable.NewColorableStderr(),
+                wski18n.T("{{.warning}} {{.kind}} {{.name}} violates policy {{.policy}}: {{.violation}}\n",
+                    map[string]interface{}{
+                        "warning": color.YellowString("warning:"),
+                        "kind": kind,
+                        "name": boldString(name),
+                        "policy": policy.File,
+                        "violation": violation}))
+        }
+        return nil
+    }
+
+    whisk.Debug(whisk.DbgError, "%s '%s' violates policy '%s': %#v\n", kind, name, policy.File, violations)
+    errStr := wski18n.T("The {{.kind}} {{.name}} violates policy {{.policy}}:\n  {{.violations}}",
+            map[string]interface{}{
+                "kind": kind,
+                "name": name,
+                "policy": policy.File,
+                "violations": strings.Join(violations, "\n  ")})
+    return whisk.MakeWskError(errors.New(errStr), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+        whisk.NO_DISPLAY_USAGE)
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "../../go-whisk/whisk"
+    "../wski18n"
+
+    "github.com/fatih/color"
+    "github.com/spf13/cobra"
+)
+
+var promoteCmd = &cobra.Command{
+    Use:   "promote ENTITY_NAME... --from PROFILE_OR_HOST[/NAMESPACE] --to PROFILE_OR_HOST[/NAMESPACE]",
+    Short: wski18n.T("copy actions, packages, triggers, and rules from one namespace to another"),
+    SilenceUsage:   true,
+    SilenceErrors:  true,
+    PreRunE: setupClientConfig,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if whiskErr := checkArgs(args, 1, len(args), "Promote",
+                wski18n.T("One or more entity names are required.")); whiskErr != nil {
+            return whiskErr
+        }
+
+        from, err := getEndpoint(flags.promote.from)
+        if err != nil {
+            return err
+        }
+
+        to, err := getEndpoint(flags.promote.to)
+        if err != nil {
+            return err
+        }
+
+        source, err := getEndpointBackup(from)
+        if err != nil {
+            return err
+        }
+
+        target, err := getEndpointBackup(to)
+        if err != nil {
+            return err
+        }
+
+        promoted, err := selectPromoteEntities(source, args, true)
+        if err != nil {
+            return err
+        }
+        addPromotePackages(promoted, source, target)
+
+        if flags.promote.dryRun {
+            existing, _ := selectPromoteEntities(target, args, false)
+            diff := diffNamespaces(existing, promoted)
+            diff.From = flags.promote.from
+            diff.To = flags.promote.to
+            printNamespaceDiff(diff)
+            return nil
+        }
+
+        var count int
+        err = withEndpoint(to, func() error {
+            var err error
+            count, _, err = restoreNamespace(promoted, target.Namespace, true, true)
+            return err
+        })
+        if err != nil {
+            return err
+        }
+
+        fmt.Fprintf(color.Output,
+            wski18n.T("{{.ok}} promoted {{.count}} entities from {{.from}} to {{.to}}\n",
+                map[string]interface{}{
+                    "ok": color.GreenString("ok:"),
+                    "count": count,
+                    "from": boldString(from.Spec),
+                    "to": boldString(to.Spec)}))
+        return nil
+    },
+}
+
+/*
+Select the named entities of a namespace.  A package brings its actions along with it.  When required is set,
+a name that matches no entity is an error; otherwise it is ignored.
+*/
+func selectPromoteEntities(backup *NamespaceBackup, names []string, required bool) (*NamespaceBackup, error) {
+    selected := &NamespaceBackup{
+        Version: backup.Version,
+        Namespace: backup.Namespace,
+        Created: backup.Created,
+    }
+
+    // An entity may be named more than once, directly or through its package, but is selected only once
+    added := make(map[string]bool)
+    isNew := func(kind string, name string) bool {
+        if added[kind + " " + name] {
+            return false
+        }
+        added[kind + " " + name] = true
+        return true
+    }
+
+    for _, name := range names {
+        qName, err := parseQualifiedName(name)
+        if err != nil {
+            whisk.Debug(whisk.DbgError, "parseQualifiedName(%s) failed: %s\n", name, err)
+            errMsg := wski18n.T("'{{.name}}' is not a valid qualified name: {{.err}}",
+                    map[string]interface{}{"name": name, "err": err})
+            whiskErr := whisk.MakeWskErrorFromWskError(errors.New(errMsg), err, whisk.EXITCODE_ERR_GENERAL,
+                whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+            return nil, whiskErr
+        }
+        name = qName.entityName
+        found := false
+
+        for _, pkg := range backup.Packages {
+            if pkg.Name != name {
+                continue
+            }
+            found = true
+            if isNew("package", name) {
+                selected.Packages = append(selected.Packages, pkg)
+            }
+            for _, action := range backup.Actions {
+                actionName := getBackupActionName(action)
+                if strings.HasPrefix(actionName, name + "/") && isNew("action", actionName) {
+                    selected.Actions = append(selected.Actions, action)
+                }
+            }
+        }
+
+        for _, action := range backup.Actions {
+            if getBackupActionName(action) == name {
+                found = true
+                if isNew("action", name) {
+                    selected.Actions = append(selected.Actions, action)
+                }
+            }
+        }
+
+        for _, trigger := range backup.Triggers {
+            if trigger.Name == name {
+                found = true
+                if isNew("trigger", name) {
+                    selected.Triggers = append(selected.Triggers, trigger)
+                }
+            }
+        }
+
+        for _, rule := range backup.Rules {
+            if rule.Name == name {
+                found = true
+                if isNew("rule", name) {
+                    selected.Rules = append(selected.Rules, rule)
+                }
+            }
+        }
+
+        if !found && required {
+            whisk.Debug(whisk.DbgError, "No entity '%s' in namespace '%s'\n", name, backup.Namespace)
+            errMsg := wski18n.T("There is no action, package, trigger, or rule named '{{.name}}' in namespace '{{.namespace}}'.",
+                    map[string]interface{}{"name": name, "namespace": backup.Namespace})
+            whiskErr := whisk.MakeWskError(errors.New(errMsg), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+                whisk.NO_DISPLAY_USAGE)
+            return nil, whiskErr
+        }
+    }
+
+    return selected, nil
+}
+
+// addPromotePackages adds the packages of the selected actions that the target namespace lacks, without their other
+// actions, so that the restore does not fail on an action after the entities before it were copied
+func addPromotePackages(selected *NamespaceBackup, source *NamespaceBackup, target *NamespaceBackup) {
+    hasPackage := func(backup *NamespaceBackup, name string) bool {
+        for _, pkg := range backup.Packages {
+            if pkg.Name == name {
+                return true
+            }
+        }
+        return false
+    }
+
+    for _, action := range selected.Actions {
+        parts := strings.SplitN(getBackupActionName(action), "/", 2)
+        if len(parts) < 2 || hasPackage(selected, parts[0]) || hasPackage(target, parts[0]) {
+            continue
+        }
+
+        for _, pkg := range source.Packages {
+            if pkg.Name == parts[0] {
+                whisk.Debug(whisk.DbgInfo, "Adding package '%s' of action '%s'\n", pkg.Name, action.Name)
+                selected.Packages = append(selected.Packages, pkg)
+            }
+        }
+    }
+}
+
+func init() {
+    promoteCmd.Flags().StringVar(&flags.promote.from, "from", "", wski18n.T("copy from the namespace of `PROFILE_OR_HOST[/NAMESPACE]`"))
+    promoteCmd.Flags().StringVar(&flags.promote.to, "to", "", wski18n.T("copy to the namespace of `PROFILE_OR_HOST[/NAMESPACE]`"))
+    promoteCmd.Flags().BoolVar(&flags.promote.dryRun, "dry-run", false, wski18n.T("list the changes without making them"))
+    promoteCmd.Flags().StringSliceVarP(&flags.common.param, "param", "p", []string{}, wski18n.T("feed parameter values in `KEY VALUE` format for promoted triggers"))
+}
@@ -40,6 +40,7 @@ var Properties struct {
     CLIVersion string
     Namespace  string
     PropsFile  string
+    Policy     string
 }
 
 const DefaultAuth       string = ""
@@ -49,6 +50,7 @@ const DefaultAPIBuild   string = ""
 const DefaultAPIBuildNo string = ""
 const DefaultNamespace  string = "_"
 const DefaultPropsFile  string = "~/.wskprops"
+const DefaultPolicy     string = ""
 
 var propertyCmd = &cobra.Command{
     Use:   "property",
@@ -147,6 +149,13 @@ var propertySetCmd = &cobra.Command{
             }
         }
 
+        if policy := flags.property.policySet; len(policy) > 0 {
+            props["POLICY"] = policy
+            okMsg += fmt.Sprintf(
+                wski18n.T("{{.ok}} whisk policy set to {{.policy}}\n",
+                    map[string]interface{}{"ok": color.GreenString("ok:"), "policy": boldString(policy)}))
+        }
+
         err = writeProps(Properties.PropsFile, props)
         if err != nil {
             whisk.Debug(whisk.DbgError, "writeProps(%s, %#v) failed: %s\n", Properties.PropsFile, props, err)
@@ -245,6 +254,15 @@ var propertyUnsetCmd = &cobra.Command{
             }
         }
 
+        if flags.property.policy {
+            delete(props, "POLICY")
+            okMsg += fmt.Sprintf(
+                wski18n.T("{{.ok}} whisk policy unset",
+                    map[string]interface{}{"ok": color.GreenString("ok:")}))
+            okMsg += fmt.Sprint(
+                wski18n.T("; entities will not be checked against a policy.\n"))
+        }
+
         err = writeProps(Properties.PropsFile, props)
         if err != nil {
             whisk.Debug(whisk.DbgError, "writeProps(%s, %#v) failed: %s\n", Properties.PropsFile, props, err)
@@ -275,7 +293,8 @@ var propertyGetCmd = &cobra.Command{
         if !(flags.property.all || flags.property.auth ||
              flags.property.apiversion || flags.property.cliversion ||
              flags.property.namespace || flags.property.apibuild ||
-             flags.property.apihost || flags.property.apibuildno) {
+             flags.property.apihost || flags.property.apibuildno ||
+             flags.property.policy) {
             flags.property.all = true
         }
 
@@ -295,6 +314,10 @@ var propertyGetCmd = &cobra.Command{
             fmt.Fprintf(color.Output, "%s\t\t%s\n", wski18n.T("whisk namespace"), boldString(Properties.Namespace))
         }
 
+        if flags.property.all || flags.property.policy {
+            fmt.Fprintf(color.Output, "%s\t\t%s\n", wski18n.T("whisk policy"), boldString(Properties.Policy))
+        }
+
         if flags.property.all || flags.property.cliversion {
             fmt.Fprintf(color.Output, "%s\t%s\n", wski18n.T("whisk CLI version"), boldString(Properties.CLIVersion))
         }
@@ -341,17 +364,20 @@ func init() {
     propertyGetCmd.Flags().BoolVar(&flags.property.apibuildno, "apibuildno", false, wski18n.T("whisk API build number"))
     propertyGetCmd.Flags().BoolVar(&flags.property.cliversion, "cliversion", false, wski18n.T("whisk CLI version"))
     propertyGetCmd.Flags().BoolVar(&flags.property.namespace, "namespace", false, wski18n.T("whisk namespace"))
+    propertyGetCmd.Flags().BoolVar(&flags.property.policy, "policy", false, wski18n.T("whisk policy file"))
     propertyGetCmd.Flags().BoolVar(&flags.property.all, "all", false, wski18n.T("all properties"))
 
     propertySetCmd.Flags().StringVarP(&flags.global.auth, "auth", "u", "", wski18n.T("authorization `KEY`"))
     propertySetCmd.Flags().StringVar(&flags.property.apihostSet, "apihost", "", wski18n.T("whisk API `HOST`"))
     propertySetCmd.Flags().StringVar(&flags.property.apiversionSet, "apiversion", "", wski18n.T("whisk API `VERSION`"))
     propertySetCmd.Flags().StringVar(&flags.property.namespaceSet, "namespace", "", wski18n.T("whisk `NAMESPACE`"))
+    propertySetCmd.Flags().StringVar(&flags.property.policySet, "policy", "", wski18n.T("whisk policy `FILE` that entities are checked against"))
 
     propertyUnsetCmd.Flags().BoolVar(&flags.property.auth, "auth", false, wski18n.T("authorization key"))
     propertyUnsetCmd.Flags().BoolVar(&flags.property.apihost, "apihost", false, wski18n.T("whisk API host"))
     propertyUnsetCmd.Flags().BoolVar(&flags.property.apiversion, "apiversion", false, wski18n.T("whisk API version"))
     propertyUnsetCmd.Flags().BoolVar(&flags.property.namespace, "namespace", false, wski18n.T("whisk namespace"))
+    propertyUnsetCmd.Flags().BoolVar(&flags.property.policy, "policy", false, wski18n.T("whisk policy file"))
 
 }
 
@@ -363,6 +389,7 @@ func setDefaultProperties() {
     Properties.APIBuildNo = DefaultAPIBuildNo
     Properties.APIVersion = DefaultAPIVersion
     Properties.PropsFile = DefaultPropsFile
+    Properties.Policy = DefaultPolicy
     // Properties.CLIVersion value is set from main's init()
 }
 
@@ -448,6 +475,10 @@ func loadProperties() error {
         Properties.Namespace = namespace
     }
 
+    if policy, hasProp := props["POLICY"]; hasProp {
+        Properties.Policy = policy
+    }
+
     return nil
 }
 
@@ -492,6 +523,10 @@ func parseConfigFlags(cmd *cobra.Command, args []string) error {
         }
     }
 
+    if policy := flags.global.policy; len(policy) > 0 {
+        Properties.Policy = policy
+    }
+
     if flags.global.debug {
         whisk.SetDebug(true)
     }
@@ -59,18 +59,19 @@ var ruleEnableCmd = &cobra.Command{
         client.Namespace = qName.namespace
         ruleName := qName.entityName
 
-        _, _, err = client.Rules.SetState(ruleName, "active")
-        if err != nil {
-            whisk.Debug(whisk.DbgError, "client.Rules.SetState(%s, active) failed: %s\n", ruleName, err)
-            errStr := wski18n.T("Unable to enable rule '{{.name}}': {{.err}}",
-                    map[string]interface{}{"name": ruleName, "err": err})
-            werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
-            return werr
+        if isNamePattern(ruleName) {
+            names, err := expandNamePattern("rule", ruleName)
+            if err != nil {
+                return err
+            }
+            return runBulk(names, enableRule, getRuleEnabledMessage)
         }
 
-        fmt.Fprintf(color.Output,
-            wski18n.T("{{.ok}} enabled rule {{.name}}\n",
-                map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(ruleName)}))
+        if err = enableRule(ruleName); err != nil {
+            return err
+        }
+
+        fmt.Fprintf(color.Output, getRuleEnabledMessage(ruleName))
         return nil
     },
 }
@@ -101,18 +102,19 @@ var ruleDisableCmd = &cobra.Command{
         client.Namespace = qName.namespace
         ruleName := qName.entityName
 
-        _, _, err = client.Rules.SetState(ruleName, "inactive")
-        if err != nil {
-            whisk.Debug(whisk.DbgError, "client.Rules.SetState(%s, inactive) failed: %s\n", ruleName, err)
-            errStr := wski18n.T("Unable to disable rule '{{.name}}': {{.err}}",
-                    map[string]interface{}{"name": ruleName, "err": err})
-            werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
-            return werr
+        if isNamePattern(ruleName) {
+            names, err := expandNamePattern("rule", ruleName)
+            if err != nil {
+                return err
+            }
+            return runBulk(names, disableRule, getRuleDisabledMessage)
         }
 
-        fmt.Fprintf(color.Output,
-            wski18n.T("{{.ok}} disabled rule {{.name}}\n",
-                map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(ruleName)}))
+        if err = disableRule(ruleName); err != nil {
+            return err
+        }
+
+        fmt.Fprintf(color.Output, getRuleDisabledMessage(ruleName))
         return nil
     },
 }
@@ -248,7 +250,11 @@ var ruleUpdateCmd = &cobra.Command{
             Action:  actionName,
         }
 
-        _, _, err = client.Rules.Insert(rule, true)
+        if len(flags.common.ifVersion) > 0 {
+            _, _, err = client.Rules.InsertIfVersion(rule, flags.common.ifVersion)
+        } else {
+            _, _, err = client.Rules.Insert(rule, true)
+        }
         if err != nil {
             whisk.Debug(whisk.DbgError, "client.Rules.Insert(%#v) failed: %s\n", rule, err)
             errStr := wski18n.T("Unable to update rule '{{.name}}': {{.err}}",
@@ -356,33 +362,80 @@ var ruleDeleteCmd = &cobra.Command{
         client.Namespace = qName.namespace
         ruleName := qName.entityName
 
-        if flags.rule.disable {
-            _, _, err := client.Rules.SetState(ruleName, "inactive")
-            if err != nil {
-                whisk.Debug(whisk.DbgError, "client.Rules.SetState(%s, inactive) failed: %s\n", ruleName, err)
-                errStr := wski18n.T("Unable to disable rule '{{.name}}': {{.err}}",
-                        map[string]interface{}{"name": ruleName, "err": err})
-                werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
-                return werr
-            }
+        if isNamePattern(ruleName) {
+            return deleteEntityPattern("rule", ruleName, nil, deleteRule, getRuleDeletedMessage)
         }
 
-        _, err = client.Rules.Delete(ruleName)
-        if err != nil {
-            whisk.Debug(whisk.DbgError, "client.Rules.Delete(%s) error: %s\n", ruleName, err)
-            errStr := wski18n.T("Unable to delete rule '{{.name}}': {{.err}}",
-                    map[string]interface{}{"name": ruleName, "err": err})
-            werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
-            return werr
+        if err = deleteRule(ruleName); err != nil {
+            return err
         }
 
-        fmt.Fprintf(color.Output,
-            wski18n.T("{{.ok}} deleted rule {{.name}}\n",
-                map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(ruleName)}))
+        fmt.Fprintf(color.Output, getRuleDeletedMessage(ruleName))
         return nil
     },
 }
 
+func enableRule(ruleName string) error {
+    _, _, err := client.Rules.SetState(ruleName, "active")
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "client.Rules.SetState(%s, active) failed: %s\n", ruleName, err)
+        errStr := wski18n.T("Unable to enable rule '{{.name}}': {{.err}}",
+                map[string]interface{}{"name": ruleName, "err": err})
+        werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return werr
+    }
+
+    return nil
+}
+
+func disableRule(ruleName string) error {
+    _, _, err := client.Rules.SetState(ruleName, "inactive")
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "client.Rules.SetState(%s, inactive) failed: %s\n", ruleName, err)
+        errStr := wski18n.T("Unable to disable rule '{{.name}}': {{.err}}",
+                map[string]interface{}{"name": ruleName, "err": err})
+        werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return werr
+    }
+
+    return nil
+}
+
+// deleteRule deletes a rule, disabling it first when --disable is given
+func deleteRule(ruleName string) error {
+    if flags.rule.disable {
+        if err := disableRule(ruleName); err != nil {
+            return err
+        }
+    }
+
+    _, err := client.Rules.Delete(ruleName)
+    if err != nil {
+        whisk.Debug(whisk.DbgError, "client.Rules.Delete(%s) error: %s\n", ruleName, err)
+        errStr := wski18n.T("Unable to delete rule '{{.name}}': {{.err}}",
+                map[string]interface{}{"name": ruleName, "err": err})
+        werr := whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+        return werr
+    }
+
+    return nil
+}
+
+func getRuleEnabledMessage(ruleName string) string {
+    return wski18n.T("{{.ok}} enabled rule {{.name}}\n",
+        map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(ruleName)})
+}
+
+func getRuleDisabledMessage(ruleName string) string {
+    return wski18n.T("{{.ok}} disabled rule {{.name}}\n",
+        map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(ruleName)})
+}
+
+func getRuleDeletedMessage(ruleName string) string {
+    return wski18n.T("{{.ok}} deleted rule {{.name}}\n",
+        map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(ruleName)})
+}
+
 var ruleListCmd = &cobra.Command{
     Use:   "list [NAMESPACE]",
     Short: wski18n.T("list all rules"),
@@ -434,7 +487,18 @@ var ruleListCmd = &cobra.Command{
 }
 
 func init() {
+    ruleEnableCmd.Flags().BoolVar(&flags.bulk.regex, "regex", false, wski18n.T("treat RULE_NAME as a regular expression matching the rules to enable"))
+    ruleEnableCmd.Flags().IntVarP(&flags.bulk.concurrency, "concurrency", "c", 10, wski18n.T("number of rules enabled at a time when RULE_NAME is a pattern"))
+
+    ruleDisableCmd.Flags().BoolVar(&flags.bulk.regex, "regex", false, wski18n.T("treat RULE_NAME as a regular expression matching the rules to disable"))
+    ruleDisableCmd.Flags().IntVarP(&flags.bulk.concurrency, "concurrency", "c", 10, wski18n.T("number of rules disabled at a time when RULE_NAME is a pattern"))
+
     ruleDeleteCmd.Flags().BoolVar(&flags.rule.disable, "disable", false, wski18n.T("automatically disable rule before deleting it"))
+    ruleDeleteCmd.Flags().BoolVar(&flags.bulk.regex, "regex", false, wski18n.T("treat RULE_NAME as a regular expression matching the rules to delete"))
+    ruleDeleteCmd.Flags().IntVarP(&flags.bulk.concurrency, "concurrency", "c", 10, wski18n.T("number of rules deleted at a time when RULE_NAME is a pattern"))
+    ruleDeleteCmd.Flags().BoolVarP(&flags.common.yes, "yes", "y", false, wski18n.T("delete without asking for confirmation"))
+
+    ruleUpdateCmd.Flags().StringVar(&flags.common.ifVersion, "if-version", "", wski18n.T("update only if the rule is still at `VERSION`, as shown by get"))
 
     ruleGetCmd.Flags().BoolVarP(&flags.rule.summary, "summary", "s", false, wski18n.T("summarize rule details"))
 
@@ -195,6 +195,10 @@ var triggerCreateCmd = &cobra.Command{
             trigger.Parameters = parameters.(whisk.KeyValueArr)
         }
 
+        if err = checkTriggerPolicy(trigger, false); err != nil {
+            return err
+        }
+
         _, _, err = client.Triggers.Insert(trigger, false)
         if err != nil {
             whisk.Debug(whisk.DbgError, "client.Triggers.Insert(%+v,false) failed: %s\n", trigger, err)
@@ -289,7 +293,19 @@ var triggerUpdateCmd = &cobra.Command{
             Annotations: annotations.(whisk.KeyValueArr),
         }
 
-        _, _, err = client.Triggers.Insert(trigger, true)
+        if err = mergeUpdateKeyValues("trigger", trigger.Name, &trigger.Parameters, &trigger.Annotations); err != nil {
+            return err
+        }
+
+        if err = checkTriggerPolicy(trigger, true); err != nil {
+            return err
+        }
+
+        if len(flags.common.ifVersion) > 0 {
+            _, _, err = client.Triggers.InsertIfVersion(trigger, flags.common.ifVersion)
+        } else {
+            _, _, err = client.Triggers.Insert(trigger, true)
+        }
         if err != nil {
             whisk.Debug(whisk.DbgError, "client.Triggers.Insert(%+v,true) failed: %s\n", trigger, err)
             errStr := wski18n.T("Unable to update trigger '{{.name}}': {{.err}}",
@@ -400,6 +416,14 @@ var triggerDeleteCmd = &cobra.Command{
 
         client.Namespace = qName.namespace
 
+        if isNamePattern(qName.entityName) {
+            return deleteEntityPattern("trigger", qName.entityName, nil, deleteTriggerAndFeed,
+                func(name string) string {
+                    return wski18n.T("{{.ok}} deleted trigger {{.name}}\n",
+                        map[string]interface{}{"ok": color.GreenString("ok:"), "name": boldString(name)})
+                })
+        }
+
         retTrigger, _, err = client.Triggers.Delete(qName.entityName)
         if err != nil {
             whisk.Debug(whisk.DbgError, "client.Triggers.Delete(%s) failed: %s\n", qName.entityName, err)
@@ -529,6 +553,15 @@ func init() {
     triggerUpdateCmd.Flags().StringVarP(&flags.common.annotFile, "annotation-file", "A", "", wski18n.T("`FILE` containing annotation values in JSON format"))
     triggerUpdateCmd.Flags().StringSliceVarP(&flags.common.param, "param", "p", []string{}, wski18n.T("parameter values in `KEY VALUE` format"))
     triggerUpdateCmd.Flags().StringVarP(&flags.common.paramFile, "param-file", "P", "", wski18n.T("`FILE` containing parameter values in JSON format"))
+    triggerUpdateCmd.Flags().StringVar(&flags.common.ifVersion, "if-version", "", wski18n.T("update only if the trigger is still at `VERSION`, as shown by get"))
+    triggerUpdateCmd.Flags().BoolVar(&flags.common.paramMerge, "param-merge", false, wski18n.T("merge the parameters into those of the existing trigger instead of replacing them"))
+    triggerUpdateCmd.Flags().StringSliceVar(&flags.common.paramRemove, "param-remove", []string{}, wski18n.T("remove the parameter `KEY` from the existing trigger"))
+    triggerUpdateCmd.Flags().BoolVar(&flags.common.annotMerge, "annotation-merge", false, wski18n.T("merge the annotations into those of the existing trigger instead of replacing them"))
+    triggerUpdateCmd.Flags().StringSliceVar(&flags.common.annotRemove, "annotation-remove", []string{}, wski18n.T("remove the annotation `KEY` from the existing trigger"))
+
+    triggerDeleteCmd.Flags().BoolVar(&flags.bulk.regex, "regex", false, wski18n.T("treat TRIGGER_NAME as a regular expression matching the triggers to delete"))
+    triggerDeleteCmd.Flags().IntVarP(&flags.bulk.concurrency, "concurrency", "c", 10, wski18n.T("number of triggers deleted at a time when TRIGGER_NAME is a pattern"))
+    triggerDeleteCmd.Flags().BoolVarP(&flags.common.yes, "yes", "y", false, wski18n.T("delete without asking for confirmation"))
 
     triggerGetCmd.Flags().BoolVarP(&flags.trigger.summary, "summary", "s", false, wski18n.T("summarize trigger details"))
 
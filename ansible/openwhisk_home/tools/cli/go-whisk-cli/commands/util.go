@@ -25,6 +25,7 @@ import (
     "../wski18n"
 
     "github.com/fatih/color"
+    "github.com/mattn/go-colorable"
     //prettyjson "github.com/hokaccha/go-prettyjson"  // See prettyjson comment below
     "archive/tar"
     "io"
@@ -36,6 +37,8 @@ import (
     "io/ioutil"
     "sort"
     "reflect"
+    "bufio"
+    "net/http"
 )
 
 type QualifiedName struct {
@@ -528,6 +531,19 @@ func getChildValueStrings(keyValueArr whisk.KeyValueArr, key string, childKey st
     return res
 }
 
+// getNumber reads a number from a decoded JSON response, which the client decodes as json.Number
+func getNumber(value interface{}) (float64, bool) {
+    switch number := value.(type) {
+    case json.Number:
+        res, err := number.Float64()
+        return res, err == nil
+    case float64:
+        return number, true
+    }
+
+    return 0, false
+}
+
 func getValueFromJSONResponse(field string, response map[string]interface {}) (interface{}) {
     var res interface{}
 
@@ -914,6 +930,129 @@ func parseShared(shared string) (bool, bool, error) {
     return isShared, isSet, nil
 }
 
+// isMergeUpdate reports whether an update merges its parameters or annotations into those of the existing entity
+func isMergeUpdate() bool {
+    return flags.common.paramMerge || len(flags.common.paramRemove) > 0 ||
+        flags.common.annotMerge || len(flags.common.annotRemove) > 0
+}
+
+/*
+Merge the parameters and annotations of an update into those of the existing action, package, binding, or
+trigger, as --param-merge, --param-remove, --annotation-merge, and --annotation-remove ask for.  Without these
+flags an update replaces the whole list.  An entity that does not exist yet has nothing to merge with.
+*/
+func mergeUpdateKeyValues(kind string, name string, parameters *whisk.KeyValueArr,
+        annotations *whisk.KeyValueArr) error {
+    var existingParameters, existingAnnotations whisk.KeyValueArr
+    var resp *http.Response
+    var err error
+
+    if !isMergeUpdate() {
+        return nil
+    }
+
+    switch kind {
+    case "action":
+        var action *whisk.Action
+        if action, resp, err = client.Actions.Get(name); err == nil {
+            existingParameters, existingAnnotations = action.Parameters, action.Annotations
+        }
+    case "package":
+        var pkg *whisk.Package
+        if pkg, resp, err = client.Packages.Get(name); err == nil {
+            existingParameters, existingAnnotations = pkg.Parameters, pkg.Annotations
+        }
+    case "trigger":
+        var trigger *whisk.Trigger
+        if trigger, resp, err = client.Triggers.Get(name); err == nil {
+            existingParameters, existingAnnotations = trigger.Parameters, trigger.Annotations
+        }
+    }
+
+    if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+        whisk.Debug(whisk.DbgError, "Get of %s '%s' failed: %s\n", kind, name, err)
+        errStr := wski18n.T("Unable to get {{.kind}} '{{.name}}' to merge the update into: {{.err}}",
+                map[string]interface{}{"kind": kind, "name": name, "err": err})
+        return whisk.MakeWskErrorFromWskError(errors.New(errStr), err, whisk.EXITCODE_ERR_NETWORK,
+            whisk.DISPLAY_MSG, whisk.NO_DISPLAY_USAGE)
+    }
+
+    if flags.common.paramMerge || len(flags.common.paramRemove) > 0 {
+        *parameters = mergeKeyValues(existingParameters, *parameters, flags.common.paramRemove, kind, name,
+            "parameter")
+        if err = checkMergedKeyValues(existingParameters, *parameters, kind, name, "parameter"); err != nil {
+            return err
+        }
+    }
+
+    if flags.common.annotMerge || len(flags.common.annotRemove) > 0 {
+        *annotations = mergeKeyValues(existingAnnotations, *annotations, flags.common.annotRemove, kind, name,
+            "annotation")
+        if err = checkMergedKeyValues(existingAnnotations, *annotations, kind, name, "annotation"); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// mergeKeyValues sets the updated keys in the existing key values, keeping their order, and then removes keys
+func mergeKeyValues(existing whisk.KeyValueArr, updates whisk.KeyValueArr, remove []string, kind string,
+        name string, field string) whisk.KeyValueArr {
+    merged := whisk.KeyValueArr{}
+    updated := make(map[string]bool)
+    removed := make(map[string]bool)
+
+    for _, key := range remove {
+        removed[key] = true
+        if getValue(existing, key) == nil && getValue(updates, key) == nil {
+            fmt.Fprintf(colorable.NewColorableStderr(),
+                wski18n.T("{{.warning}} {{.kind}} {{.name}} has no {{.field}} '{{.key}}' to remove\n",
+                    map[string]interface{}{
+                        "warning": color.YellowString("warning:"),
+                        "kind": kind,
+                        "name": name,
+                        "field": field,
+                        "key": key}))
+        }
+    }
+
+    for _, keyValue := range existing {
+        for _, update := range updates {
+            if update.Key == keyValue.Key {
+                keyValue = update
+            }
+        }
+        updated[keyValue.Key] = true
+        if !removed[keyValue.Key] {
+            merged = append(merged, keyValue)
+        }
+    }
+
+    for _, update := range updates {
+        if !updated[update.Key] && !removed[update.Key] {
+            updated[update.Key] = true
+            merged = append(merged, update)
+        }
+    }
+
+    return merged
+}
+
+// checkMergedKeyValues refuses to remove every key: an update without keys leaves the existing ones in place
+func checkMergedKeyValues(existing whisk.KeyValueArr, merged whisk.KeyValueArr, kind string, name string,
+        field string) error {
+    if len(existing) == 0 || len(merged) > 0 {
+        return nil
+    }
+
+    whisk.Debug(whisk.DbgError, "Merged %ss of %s '%s' are empty\n", field, kind, name)
+    errStr := wski18n.T("Unable to remove every {{.field}} of {{.kind}} '{{.name}}'; an update without any {{.field}}s keeps the existing ones.",
+            map[string]interface{}{"field": field, "kind": kind, "name": name})
+    return whisk.MakeWskError(errors.New(errStr), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+        whisk.NO_DISPLAY_USAGE)
+}
+
 func max(a int, b int) int {
     if (a > b) {
         return a
@@ -927,3 +1066,28 @@ func min (a int, b int) int {
     }
     return b
 }
+
+// confirmDelete lists the entities a command is about to delete and asks to go ahead, unless --yes is given
+func confirmDelete(items []string) error {
+    if flags.common.yes {
+        return nil
+    }
+
+    // The prompt goes to stderr so that it does not mix with output that is piped on
+    stderr := colorable.NewColorableStderr()
+    fmt.Fprintln(stderr, wski18n.T("The following will be deleted:"))
+    for _, item := range items {
+        fmt.Fprintf(stderr, "    %s\n", item)
+    }
+    fmt.Fprint(stderr, wski18n.T("Continue? [y/N] "))
+
+    answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+    if answer = strings.ToLower(strings.TrimSpace(answer)); answer == "y" || answer == "yes" {
+        return nil
+    }
+
+    whisk.Debug(whisk.DbgInfo, "Delete not confirmed; answer was '%s'\n", answer)
+    errStr := wski18n.T("Nothing was deleted.")
+    return whisk.MakeWskError(errors.New(errStr), whisk.EXITCODE_ERR_GENERAL, whisk.DISPLAY_MSG,
+        whisk.NO_DISPLAY_USAGE)
+}
@@ -48,6 +48,9 @@ func init() {
         namespaceCmd,
         listCmd,
         apiCmd,
+        deployCmd,
+        undeployCmd,
+        promoteCmd,
     )
 
     WskCmd.PersistentFlags().BoolVarP(&flags.global.verbose, "verbose", "v", false, wski18n.T("verbose output"))
@@ -56,4 +59,5 @@ func init() {
     WskCmd.PersistentFlags().StringVar(&flags.global.apihost, "apihost", "", wski18n.T("whisk API `HOST`"))
     WskCmd.PersistentFlags().StringVar(&flags.global.apiversion, "apiversion", "", wski18n.T("whisk API `VERSION`"))
     WskCmd.PersistentFlags().BoolVarP(&flags.global.insecure, "insecure", "i", false, wski18n.T("bypass certificate checking"))
+    WskCmd.PersistentFlags().StringVar(&flags.global.policy, "policy", "", wski18n.T("policy `FILE` that created and updated entities are checked against"))
 }
@@ -0,0 +1,203 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package actionproxy runs a Go function as an OpenWhisk action.
+
+The package implements the action-proxy protocol used by the invoker: the runtime container listens on port 8080
+and receives the action code with a POST to /init, then the action parameters with a POST to /run for every
+activation.  Because the handler is compiled into the binary, the code sent to /init is ignored.
+
+When the binary is packaged as the "exec" file of a blackbox action, the dockerskeleton image runs it with the
+parameters as its only argument and takes the last line written to stdout as the result.  Main supports both.
+
+The action is built against the package on the GOPATH, so it must be installed as $GOPATH/src/github.com/go-whisk/actionproxy:
+
+    package main
+
+    import "github.com/go-whisk/actionproxy"
+
+    func hello(params map[string]interface{}) (map[string]interface{}, error) {
+        return map[string]interface{}{"greeting": "Hello " + params["name"].(string)}, nil
+    }
+
+    func main() {
+        actionproxy.Main(hello)
+    }
+*/
+package actionproxy
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "os"
+    "sync"
+)
+
+// Written to stdout and stderr after each activation so that the invoker can tell the logs of activations apart
+const ActivationLogSentinel = "XXX_THE_END_OF_A_WHISK_ACTIVATION_XXX"
+
+const DefaultAddr = ":8080"
+
+// Handler is the action: it receives the activation parameters and returns the result
+type Handler func(map[string]interface{}) (map[string]interface{}, error)
+
+type runRequest struct {
+    Value           map[string]interface{}  `json:"value"`
+    Namespace       string                  `json:"namespace"`
+    ActionName      string                  `json:"action_name"`
+    ActivationId    string                  `json:"activation_id"`
+    Deadline        string                  `json:"deadline"`
+    ApiKey          string                  `json:"api_key"`
+}
+
+// Proxy serves the /init and /run endpoints for a handler
+type Proxy struct {
+    handler     Handler
+    lock        sync.Mutex
+    initialized bool
+}
+
+func NewProxy(handler Handler) *Proxy {
+    return &Proxy{handler: handler}
+}
+
+// Main runs the handler once with the parameters given as the first program argument, as the dockerskeleton
+// does, or serves the action-proxy protocol when no argument is given
+func Main(handler Handler) {
+    if len(os.Args) > 1 {
+        os.Exit(RunOnce(handler, os.Args[1]))
+    }
+
+    if err := http.ListenAndServe(DefaultAddr, NewProxy(handler)); err != nil {
+        fmt.Fprintf(os.Stderr, "%s\n", err)
+        os.Exit(1)
+    }
+}
+
+// RunOnce calls the handler with the JSON encoded parameters and writes the result as a single line of JSON to
+// stdout.  It returns the exit code for the process.
+func RunOnce(handler Handler, params string) int {
+    var value map[string]interface{}
+
+    if err := json.Unmarshal([]byte(params), &value); err != nil {
+        writeJSON(os.Stdout, map[string]interface{}{"error": fmt.Sprintf("invalid parameters: %s", err)})
+        return 1
+    }
+
+    result, err := handler(value)
+    if err != nil {
+        writeJSON(os.Stdout, map[string]interface{}{"error": err.Error()})
+        return 1
+    }
+
+    writeJSON(os.Stdout, result)
+    return 0
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Method != "POST" {
+        writeResponse(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "only POST is supported"})
+        return
+    }
+
+    switch r.URL.Path {
+    case "/init":
+        p.init(w, r)
+    case "/run":
+        p.run(w, r)
+    default:
+        writeResponse(w, http.StatusNotFound, map[string]interface{}{"error": "not found"})
+    }
+}
+
+func (p *Proxy) init(w http.ResponseWriter, r *http.Request) {
+    p.lock.Lock()
+    defer p.lock.Unlock()
+
+    ioutil.ReadAll(r.Body)
+
+    if p.initialized {
+        writeResponse(w, http.StatusForbidden, map[string]interface{}{"error": "cannot initialize the action more than once"})
+        return
+    }
+
+    p.initialized = true
+    writeResponse(w, http.StatusOK, map[string]interface{}{"OK": true})
+}
+
+// run calls the handler with the activation parameters.  Activations are run one at a time, as the invoker
+// expects, with the activation details passed in the environment.
+func (p *Proxy) run(w http.ResponseWriter, r *http.Request) {
+    var request runRequest
+
+    p.lock.Lock()
+    defer p.lock.Unlock()
+    defer writeSentinel()
+
+    if !p.initialized {
+        writeResponse(w, http.StatusBadGateway, map[string]interface{}{"error": "cannot run an uninitialized action"})
+        return
+    }
+
+    if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+        writeResponse(w, http.StatusBadRequest, map[string]interface{}{"error": fmt.Sprintf("invalid request: %s", err)})
+        return
+    }
+
+    os.Setenv("__OW_API_KEY", request.ApiKey)
+    os.Setenv("__OW_NAMESPACE", request.Namespace)
+    os.Setenv("__OW_ACTION_NAME", request.ActionName)
+    os.Setenv("__OW_ACTIVATION_ID", request.ActivationId)
+    os.Setenv("__OW_DEADLINE", request.Deadline)
+
+    if request.Value == nil {
+        request.Value = make(map[string]interface{})
+    }
+
+    result, err := p.handler(request.Value)
+    if err != nil {
+        writeResponse(w, http.StatusBadGateway, map[string]interface{}{"error": err.Error()})
+        return
+    }
+    if result == nil {
+        result = make(map[string]interface{})
+    }
+
+    writeResponse(w, http.StatusOK, result)
+}
+
+func writeResponse(w http.ResponseWriter, status int, body interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    writeJSON(w, body)
+}
+
+func writeJSON(w io.Writer, body interface{}) {
+    data, err := json.Marshal(body)
+    if err != nil {
+        data = []byte(fmt.Sprintf(`{"error": %q}`, err.Error()))
+    }
+    w.Write(append(data, '\n'))
+}
+
+func writeSentinel() {
+    fmt.Fprintln(os.Stdout, ActivationLogSentinel)
+    fmt.Fprintln(os.Stderr, ActivationLogSentinel)
+}
@@ -131,6 +131,25 @@ func (s *ActionService) Insert(action *Action, overwrite bool) (*Action, *http.R
     return a, resp, nil
 }
 
+/*
+Update an action only if it is still at the given version, the version read by an earlier Get.  The
+controller has no conditional update, so the version is checked by a Get just before the update; this narrows
+the window in which a concurrent update is overwritten but does not close it.
+*/
+func (s *ActionService) InsertIfVersion(action *Action, version string) (*Action, *http.Response, error) {
+    current, resp, err := s.Get(action.Name)
+    if err != nil {
+        Debug(DbgError, "s.Get(%s) error: '%s'\n", action.Name, err)
+        return nil, resp, err
+    }
+
+    if err = checkVersion("action", action.Name, current.Version, version); err != nil {
+        return nil, resp, err
+    }
+
+    return s.Insert(action, true)
+}
+
 func (s *ActionService) Get(actionName string) (*Action, *http.Response, error) {
     // Encode resource name as a path (with no query params) before inserting it into the URI
     // This way any '?' chars in the name won't be treated as the beginning of the query params
@@ -19,6 +19,8 @@ package whisk
 import (
     "net/http"
     "errors"
+    "strings"
+    "encoding/json"
     "../wski18n"
 )
 
@@ -100,16 +102,59 @@ type RetApi struct {
     Swagger         *ApiSwagger `json:"apidoc,omitempty"`
 }
 
+// The swagger types only model the fields the CLI changes.  Extra keeps every other field, such as definitions or
+// the other gateway settings, so that a swagger read from the gateway or a config file is written back whole.
 type ApiSwagger struct {
     SwaggerName     string    `json:"swagger,omitempty"`
     BasePath        string    `json:"basePath,omitempty"`
     Info            *ApiSwaggerInfo `json:"info,omitempty"`
     Paths           map[string]map[string]map[string]map[string]interface{} `json:"paths,omitempty"`
+    SecurityDef     map[string]*ApiSwaggerSecurityDef `json:"securityDefinitions,omitempty"`
+    Security        []map[string][]string `json:"security,omitempty"`
+    XConfig         *ApiSwaggerXConfig `json:"x-ibm-configuration,omitempty"`
+    Extra           map[string]json.RawMessage `json:"-"`
 }
 
 type ApiSwaggerInfo struct {
     Title           string    `json:"title,omitempty"`
     Version         string    `json:"version,omitempty"`
+    Extra           map[string]json.RawMessage `json:"-"`
+}
+
+type ApiSwaggerSecurityDef struct {
+    Type            string    `json:"type"`
+    In              string    `json:"in,omitempty"`
+    Name            string    `json:"name,omitempty"`
+    Extra           map[string]json.RawMessage `json:"-"`
+}
+
+type ApiSwaggerXConfig struct {
+    Cors            *ApiSwaggerCors `json:"cors,omitempty"`
+    Extra           map[string]json.RawMessage `json:"-"`
+}
+
+type ApiSwaggerCors struct {
+    Enabled         bool      `json:"enabled"`
+    Extra           map[string]json.RawMessage `json:"-"`
+}
+
+type ApiRateLimit struct {
+    Rate            int       `json:"rate"`
+    Unit            string    `json:"unit"`
+}
+
+// Swagger extension on an operation holding its ApiRateLimit
+const ApiRateLimitExt = "x-ibm-rate-limit"
+
+// Security definition added by RequireApiKey; callers pass the key in this header
+const ApiKeySecurityName = "client_id"
+const ApiKeyHeader = "X-IBM-Client-Id"
+
+var ApiRateLimitUnits map[string]bool = map[string]bool {
+    "second": true,
+    "minute": true,
+    "hour": true,
+    "day": true,
 }
 
 
@@ -123,6 +168,138 @@ var ApiVerbs map[string]bool = map[string]bool {
     "OPTIONS": true,
 }
 
+////////////////////////
+// Swagger Extensions //
+////////////////////////
+
+// Each swagger type is converted to a local type without methods so that the default encoding is used for the
+// modeled fields
+func (s *ApiSwagger) UnmarshalJSON(data []byte) error {
+    type apiSwagger ApiSwagger
+    return unmarshalWithExtra(data, (*apiSwagger)(s), &s.Extra)
+}
+
+func (s ApiSwagger) MarshalJSON() ([]byte, error) {
+    type apiSwagger ApiSwagger
+    return marshalWithExtra(apiSwagger(s), s.Extra)
+}
+
+func (i *ApiSwaggerInfo) UnmarshalJSON(data []byte) error {
+    type apiSwaggerInfo ApiSwaggerInfo
+    return unmarshalWithExtra(data, (*apiSwaggerInfo)(i), &i.Extra)
+}
+
+func (i ApiSwaggerInfo) MarshalJSON() ([]byte, error) {
+    type apiSwaggerInfo ApiSwaggerInfo
+    return marshalWithExtra(apiSwaggerInfo(i), i.Extra)
+}
+
+func (d *ApiSwaggerSecurityDef) UnmarshalJSON(data []byte) error {
+    type apiSwaggerSecurityDef ApiSwaggerSecurityDef
+    return unmarshalWithExtra(data, (*apiSwaggerSecurityDef)(d), &d.Extra)
+}
+
+func (d ApiSwaggerSecurityDef) MarshalJSON() ([]byte, error) {
+    type apiSwaggerSecurityDef ApiSwaggerSecurityDef
+    return marshalWithExtra(apiSwaggerSecurityDef(d), d.Extra)
+}
+
+func (x *ApiSwaggerXConfig) UnmarshalJSON(data []byte) error {
+    type apiSwaggerXConfig ApiSwaggerXConfig
+    return unmarshalWithExtra(data, (*apiSwaggerXConfig)(x), &x.Extra)
+}
+
+func (x ApiSwaggerXConfig) MarshalJSON() ([]byte, error) {
+    type apiSwaggerXConfig ApiSwaggerXConfig
+    return marshalWithExtra(apiSwaggerXConfig(x), x.Extra)
+}
+
+func (c *ApiSwaggerCors) UnmarshalJSON(data []byte) error {
+    type apiSwaggerCors ApiSwaggerCors
+    return unmarshalWithExtra(data, (*apiSwaggerCors)(c), &c.Extra)
+}
+
+func (c ApiSwaggerCors) MarshalJSON() ([]byte, error) {
+    type apiSwaggerCors ApiSwaggerCors
+    return marshalWithExtra(apiSwaggerCors(c), c.Extra)
+}
+
+func (s *ApiSwagger) SetCors(enabled bool) {
+    if s.XConfig == nil {
+        s.XConfig = new(ApiSwaggerXConfig)
+    }
+    if s.XConfig.Cors == nil {
+        s.XConfig.Cors = new(ApiSwaggerCors)
+    }
+    s.XConfig.Cors.Enabled = enabled
+}
+
+func (s *ApiSwagger) CorsEnabled() bool {
+    return s.XConfig != nil && s.XConfig.Cors != nil && s.XConfig.Cors.Enabled
+}
+
+// RequireApiKey adds an API key security definition and applies it to every operation in the API
+func (s *ApiSwagger) RequireApiKey() {
+    if s.SecurityDef == nil {
+        s.SecurityDef = make(map[string]*ApiSwaggerSecurityDef)
+    }
+    s.SecurityDef[ApiKeySecurityName] = &ApiSwaggerSecurityDef{Type: "apiKey", In: "header", Name: ApiKeyHeader}
+
+    for _, requirement := range s.Security {
+        if _, ok := requirement[ApiKeySecurityName]; ok {
+            return
+        }
+    }
+    s.Security = append(s.Security, map[string][]string{ApiKeySecurityName: []string{}})
+}
+
+func (s *ApiSwagger) ApiKeyRequired() bool {
+    for _, requirement := range s.Security {
+        if _, ok := requirement[ApiKeySecurityName]; ok {
+            return s.SecurityDef[ApiKeySecurityName] != nil
+        }
+    }
+    return false
+}
+
+// SetRateLimit sets the rate limit of the operation for relpath and verb; it returns false when the
+// operation does not exist
+func (s *ApiSwagger) SetRateLimit(relpath string, verb string, limit *ApiRateLimit) bool {
+    operation, ok := s.Paths[relpath][strings.ToLower(verb)]
+    if !ok {
+        return false
+    }
+
+    if limit == nil {
+        delete(operation, ApiRateLimitExt)
+    } else {
+        operation[ApiRateLimitExt] = map[string]interface{}{"rate": limit.Rate, "unit": limit.Unit}
+    }
+    return true
+}
+
+func (s *ApiSwagger) GetRateLimit(relpath string, verb string) *ApiRateLimit {
+    ext, ok := s.Paths[relpath][strings.ToLower(verb)][ApiRateLimitExt]
+    if !ok {
+        return nil
+    }
+
+    limit := new(ApiRateLimit)
+    limit.Unit, _ = ext["unit"].(string)
+    switch rate := ext["rate"].(type) {
+    case json.Number:
+        if value, err := rate.Int64(); err == nil {
+            limit.Rate = int(value)
+        }
+    case float64:
+        limit.Rate = int(rate)
+    case int:
+        limit.Rate = rate
+    }
+
+    return limit
+}
+
 ////////////////////
 // Api Methods //
 ////////////////////
@@ -164,6 +164,21 @@ func (s *PackageService) Insert(x_package PackageInterface, overwrite bool) (*Pa
     return p, resp, nil
 }
 
+// Update the package only if it is still at the version read by an earlier Get, as ActionService.InsertIfVersion does
+func (s *PackageService) InsertIfVersion(x_package PackageInterface, version string) (*Package, *http.Response, error) {
+    current, resp, err := s.Get(x_package.GetName())
+    if err != nil {
+        Debug(DbgError, "s.Get(%s) error: '%s'\n", x_package.GetName(), err)
+        return nil, resp, err
+    }
+
+    if err = checkVersion("package", x_package.GetName(), current.Version, version); err != nil {
+        return nil, resp, err
+    }
+
+    return s.Insert(x_package, true)
+}
+
 func (s *PackageService) Delete(packageName string) (*http.Response, error) {
     // Encode resource name as a path (with no query params) before inserting it into the URI
     // This way any '?' chars in the name won't be treated as the beginning of the query params
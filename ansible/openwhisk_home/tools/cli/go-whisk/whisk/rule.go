@@ -101,6 +101,21 @@ func (s *RuleService) Insert(rule *Rule, overwrite bool) (*Rule, *http.Response,
     return r, resp, nil
 }
 
+// Update the rule only if it is still at the version read by an earlier Get, as ActionService.InsertIfVersion does
+func (s *RuleService) InsertIfVersion(rule *Rule, version string) (*Rule, *http.Response, error) {
+    current, resp, err := s.Get(rule.Name)
+    if err != nil {
+        Debug(DbgError, "s.Get(%s) error: '%s'\n", rule.Name, err)
+        return nil, resp, err
+    }
+
+    if err = checkVersion("rule", rule.Name, current.Version, version); err != nil {
+        return nil, resp, err
+    }
+
+    return s.Insert(rule, true)
+}
+
 func (s *RuleService) Get(ruleName string) (*Rule, *http.Response, error) {
     // Encode resource name as a path (with no query params) before inserting it into the URI
     // This way any '?' chars in the name won't be treated as the beginning of the query params
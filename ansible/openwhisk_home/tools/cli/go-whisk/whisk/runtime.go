@@ -0,0 +1,169 @@
+/*
+ * Copyright 2015-2016 IBM Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package whisk
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "net/http"
+    "net/url"
+    "../wski18n"
+)
+
+// RuntimeClient talks directly to an action runtime container using the /init and /run action-proxy protocol
+// that the invoker uses
+type RuntimeClient struct {
+    client  *http.Client
+    BaseURL *url.URL
+}
+
+type RuntimeInit struct {
+    Value   RuntimeInitValue    `json:"value"`
+}
+
+type RuntimeInitValue struct {
+    Name    string  `json:"name"`
+    Main    string  `json:"main"`
+    Code    string  `json:"code"`
+    Binary  bool    `json:"binary"`
+}
+
+type RuntimeRun struct {
+    Value           interface{}     `json:"value"`
+    Namespace       string          `json:"namespace"`
+    ActionName      string          `json:"action_name"`
+    ActivationId    string          `json:"activation_id"`
+    Deadline        string          `json:"deadline"`
+    ApiKey          string          `json:"api_key,omitempty"`
+}
+
+func NewRuntimeClient(httpClient *http.Client, baseURL *url.URL) *RuntimeClient {
+    if httpClient == nil {
+        httpClient = http.DefaultClient
+    }
+
+    return &RuntimeClient{client: httpClient, BaseURL: baseURL}
+}
+
+// Init sends the action code to the runtime.  Binary code, such as a zip archive, must be base64 encoded.
+func (c *RuntimeClient) Init(action *Action, binary bool) (*http.Response, error) {
+    init := &RuntimeInit{
+        Value: RuntimeInitValue{
+            Name: action.Name,
+            Main: "main",
+        },
+    }
+
+    if action.Exec != nil {
+        if len(action.Exec.Main) > 0 {
+            init.Value.Main = action.Exec.Main
+        }
+
+        if len(action.Exec.Jar) > 0 {
+            init.Value.Code = action.Exec.Jar
+            init.Value.Binary = true
+        } else if action.Exec.Code != nil {
+            init.Value.Code = *action.Exec.Code
+            init.Value.Binary = binary
+        }
+    }
+
+    return c.post("init", init, nil)
+}
+
+// Run invokes the initialized action with the given parameters and returns its result
+func (c *RuntimeClient) Run(run *RuntimeRun) (map[string]interface{}, *http.Response, error) {
+    var res map[string]interface{}
+
+    resp, err := c.post("run", run, &res)
+
+    return res, resp, err
+}
+
+func (c *RuntimeClient) post(route string, body interface{}, v interface{}) (*http.Response, error) {
+    routeUrl, err := c.BaseURL.Parse(route)
+    if err != nil {
+        Debug(DbgError, "url.Parse(%s) error: %s\n", route, err)
+        errStr := wski18n.T("Unable to create request URL '{{.url}}': {{.err}}",
+            map[string]interface{}{"url": route, "err": err})
+        werr := MakeWskError(errors.New(errStr), EXITCODE_ERR_GENERAL, DISPLAY_MSG, NO_DISPLAY_USAGE)
+        return nil, werr
+    }
+
+    data, err := json.Marshal(body)
+    if err != nil {
+        Debug(DbgError, "json.Marshal(%#v) error: %s\n", body, err)
+        errStr := wski18n.T("Error encoding request body: {{.err}}", map[string]interface{}{"err": err})
+        werr := MakeWskError(errors.New(errStr), EXITCODE_ERR_GENERAL, DISPLAY_MSG, NO_DISPLAY_USAGE)
+        return nil, werr
+    }
+
+    Verbose("REQUEST:\n[POST]\t%s\n", routeUrl)
+    resp, err := c.client.Post(routeUrl.String(), "application/json", bytes.NewReader(data))
+    if err != nil {
+        Debug(DbgError, "HTTP Post() [%s] error: %s\n", routeUrl, err)
+        werr := MakeWskError(err, EXITCODE_ERR_NETWORK, DISPLAY_MSG, NO_DISPLAY_USAGE)
+        return nil, werr
+    }
+    defer resp.Body.Close()
+
+    data, err = ioutil.ReadAll(resp.Body)
+    if err != nil {
+        Debug(DbgError, "ioutil.ReadAll(resp.Body) error: %s\n", err)
+        werr := MakeWskError(err, EXITCODE_ERR_NETWORK, DISPLAY_MSG, NO_DISPLAY_USAGE)
+        return resp, werr
+    }
+    Verbose("RESPONSE:\nGot response with code %d\n", resp.StatusCode)
+    Verbose("Response body received:\n%s\n", string(data))
+
+    if v != nil && len(data) > 0 {
+        if err = json.Unmarshal(data, v); err != nil {
+            Debug(DbgWarn, "json.Unmarshal(%s) error: %s\n", data, err)
+        }
+    }
+
+    if !IsHttpRespSuccess(resp) {
+        var errorResponse map[string]interface{}
+        errMsg := string(data)
+
+        isErrorResponse := json.Unmarshal(data, &errorResponse) == nil && errorResponse["error"] != nil
+        if isErrorResponse {
+            errMsg = fmt.Sprintf("%v", errorResponse["error"])
+        }
+
+        // The runtimes report an error raised by the action as a 502 with an error object, as the invoker
+        // expects.  Every other failure, including any failure of /init, is a failure of the runtime itself.
+        if route == "run" && resp.StatusCode == http.StatusBadGateway && isErrorResponse {
+            Debug(DbgError, "Action failed in runtime: %s\n", errMsg)
+            errStr := wski18n.T("The action failed: {{.err}}", map[string]interface{}{"err": errMsg})
+            werr := MakeWskError(errors.New(errStr), resp.StatusCode - 256, DISPLAY_MSG, NO_DISPLAY_USAGE,
+                NO_MSG_DISPLAYED, APPLICATION_ERR)
+            return resp, werr
+        }
+
+        Debug(DbgError, "Runtime %s failed with status %d: %s\n", route, resp.StatusCode, errMsg)
+        errStr := wski18n.T("The runtime failed to {{.route}} the action with status {{.status}}: {{.err}}",
+            map[string]interface{}{"route": route, "status": resp.StatusCode, "err": errMsg})
+        werr := MakeWskError(errors.New(errStr), resp.StatusCode - 256, DISPLAY_MSG, NO_DISPLAY_USAGE)
+        return resp, werr
+    }
+
+    return resp, nil
+}
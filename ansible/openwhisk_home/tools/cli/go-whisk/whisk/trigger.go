@@ -112,6 +112,21 @@ func (s *TriggerService) Insert(trigger *Trigger, overwrite bool) (*Trigger, *ht
 
 }
 
+// Update the trigger only if it is still at the version read by an earlier Get, as ActionService.InsertIfVersion does
+func (s *TriggerService) InsertIfVersion(trigger *Trigger, version string) (*Trigger, *http.Response, error) {
+    current, resp, err := s.Get(trigger.Name)
+    if err != nil {
+        Debug(DbgError, "s.Get(%s) error: '%s'\n", trigger.Name, err)
+        return nil, resp, err
+    }
+
+    if err = checkVersion("trigger", trigger.Name, current.Version, version); err != nil {
+        return nil, resp, err
+    }
+
+    return s.Insert(trigger, true)
+}
+
 func (s *TriggerService) Get(triggerName string) (*Trigger, *http.Response, error) {
     // Encode resource name as a path (with no query params) before inserting it into the URI
     // This way any '?' chars in the name won't be treated as the beginning of the query params
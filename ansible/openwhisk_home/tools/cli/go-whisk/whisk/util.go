@@ -17,10 +17,12 @@
 package whisk
 
 import (
+    "encoding/json"
     "errors"
     "fmt"
     "net/url"
     "reflect"
+    "strings"
 
     "github.com/fatih/color"
     "github.com/google/go-querystring/query"
@@ -64,3 +66,61 @@ func printJSON(v interface{}) {
     output, _ := prettyjson.Marshal(v)
     fmt.Fprintln(color.Output, string(output))
 }
+
+// checkVersion refuses an update when the entity changed after the version the update is based on was read
+func checkVersion(kind string, name string, current string, version string) error {
+    if current == version {
+        return nil
+    }
+
+    Debug(DbgError, "%s '%s' is at version %s rather than %s\n", kind, name, current, version)
+    errStr := wski18n.T("The {{.kind}} '{{.name}}' was changed to version {{.current}} after version {{.version}} was read; get it again and retry the update.",
+        map[string]interface{}{"kind": kind, "name": name, "current": current, "version": version})
+    return MakeWskError(errors.New(errStr), EXITCODE_ERR_GENERAL, DISPLAY_MSG, NO_DISPLAY_USAGE)
+}
+
+// unmarshalWithExtra decodes data into the struct v and keeps the fields v has no JSON field for in extra
+func unmarshalWithExtra(data []byte, v interface{}, extra *map[string]json.RawMessage) error {
+    var fields map[string]json.RawMessage
+
+    if err := json.Unmarshal(data, v); err != nil {
+        return err
+    }
+    if err := json.Unmarshal(data, &fields); err != nil {
+        return err
+    }
+
+    structType := reflect.TypeOf(v).Elem()
+    for i := 0; i < structType.NumField(); i++ {
+        name := strings.Split(structType.Field(i).Tag.Get("json"), ",")[0]
+        delete(fields, name)
+    }
+
+    *extra = nil
+    if len(fields) > 0 {
+        *extra = fields
+    }
+
+    return nil
+}
+
+// marshalWithExtra encodes the struct v together with the extra fields it was decoded with
+func marshalWithExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+    var fields map[string]json.RawMessage
+
+    data, err := json.Marshal(v)
+    if err != nil || len(extra) == 0 {
+        return data, err
+    }
+
+    if err = json.Unmarshal(data, &fields); err != nil {
+        return nil, err
+    }
+    for name, value := range extra {
+        if _, exists := fields[name]; !exists {
+            fields[name] = value
+        }
+    }
+
+    return json.Marshal(fields)
+}